@@ -1,28 +1,168 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"fullfillment-service/config"
+	"fullfillment-service/internal/auth"
+	"fullfillment-service/internal/certs"
+	"fullfillment-service/internal/events"
 	"fullfillment-service/internal/fulfillment"
+	"fullfillment-service/internal/logging"
+	"fullfillment-service/internal/metrics"
+	"fullfillment-service/internal/tracing"
 	pb "fullfillment-service/proto"
-	"log"
+	"log/slog"
 	"net"
+	"net/http"
+	"os"
+	"time"
 
+	"github.com/nats-io/nats.go"
+	"github.com/prometheus/client_golang/prometheus"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 func main() {
+	if err := run(); err != nil {
+		slog.Error("fulfillment service stopped", "error", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the service and blocks until it fails. It returns instead of
+// exiting so that deferred cleanup, such as flushing traces, still happens.
+func run() error {
+	cfg := config.Load()
+	logger, err := logging.New(cfg.Logging, os.Stderr)
+	if err != nil {
+		return fmt.Errorf("failed to set up logging: %w", err)
+	}
+	slog.SetDefault(logger)
+
+	// InitDB exits on failure, so it runs before anything needs cleaning up.
 	db := config.InitDB()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %w", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	if err := tracing.InstrumentDB(db); err != nil {
+		return fmt.Errorf("failed to instrument database: %w", err)
+	}
+
 	lis, err := net.Listen("tcp", ":50051")
 	if err != nil {
-		log.Fatalf("Failed to listen: %v", err)
+		return fmt.Errorf("failed to listen: %w", err)
 	}
 
-	grpcServer := grpc.NewServer()
-	pb.RegisterFulfillmentServiceServer(grpcServer, fulfillment.NewService(db))
+	var conn *nats.Conn
+	if cfg.EventBus == "nats" || cfg.OrderSource == "nats" {
+		conn, err = nats.Connect(cfg.NATSURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to NATS: %w", err)
+		}
+		defer conn.Close()
+	}
 
-	log.Println("Fulfillment Service is running on port 50051...")
-	if err := grpcServer.Serve(lis); err != nil {
-		log.Fatalf("Failed to serve: %v", err)
+	opts := []fulfillment.Option{fulfillment.WithConfig(cfg.Fulfillment)}
+	switch cfg.EventBus {
+	case "log":
+	case "nats":
+		opts = append(opts, fulfillment.WithPublisher(events.NewNATSPublisher(conn, cfg.NATSSubjectPrefix)))
+	default:
+		return fmt.Errorf("unknown event bus %q", cfg.EventBus)
 	}
+
+	service := fulfillment.NewService(db, opts...)
+	go service.RunOfferExpiry(context.Background(), time.Second)
+	go service.RunRedispatcher(context.Background(), 15*time.Second)
+	go service.RunDriverSweeper(context.Background(), 15*time.Second)
+	go service.RunScheduler(context.Background(), 30*time.Second)
+	go service.RunSLAMonitor(context.Background(), 30*time.Second)
+	go service.RunOutboxRelay(context.Background(), time.Second)
+	go service.RunWebhookDispatcher(context.Background(), 5*time.Second)
+
+	switch cfg.OrderSource {
+	case "":
+	case "postgres":
+		go service.RunOrderInbox(context.Background(), time.Second)
+	case "nats":
+		// Events that fail on arrival are retried from the inbox.
+		consumer := events.NewNATSConsumer(conn, cfg.NATSOrderSubject, service.HandleOrderCreated, service.QueueOrderEvent)
+		if _, err := consumer.Subscribe(context.Background(), cfg.NATSOrderQueue); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", cfg.NATSOrderSubject, err)
+		}
+		go service.RunOrderInbox(context.Background(), time.Second)
+	default:
+		return fmt.Errorf("unknown order source %q", cfg.OrderSource)
+	}
+
+	serveErr := make(chan error, 2)
+	if cfg.MetricsAddr != "" {
+		prometheus.MustRegister(service.Collector())
+		sqlDB, err := db.DB()
+		if err != nil {
+			return fmt.Errorf("failed to get database pool: %w", err)
+		}
+		if err := metrics.RegisterDBStats(sqlDB, "fulfillmentdb"); err != nil {
+			return fmt.Errorf("failed to register database metrics: %w", err)
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler())
+		go func() {
+			serveErr <- fmt.Errorf("failed to serve metrics: %w", http.ListenAndServe(cfg.MetricsAddr, mux))
+		}()
+	}
+
+	unary := []grpc.UnaryServerInterceptor{logging.UnaryServerInterceptor(logger), metrics.UnaryServerInterceptor}
+	stream := []grpc.StreamServerInterceptor{logging.StreamServerInterceptor(logger)}
+	if cfg.Auth.Enabled {
+		keys, err := auth.LoadJWKS(cfg.Auth.JWKSFile)
+		if err != nil {
+			return fmt.Errorf("failed to load JWKS from %s: %w", cfg.Auth.JWKSFile, err)
+		}
+		authenticator := auth.NewAuthenticator(cfg.Auth, keys)
+		unary = append(unary, auth.UnaryServerInterceptor(authenticator, auth.DefaultPolicy))
+		stream = append(stream, auth.StreamServerInterceptor(authenticator, auth.DefaultPolicy))
+	} else {
+		logger.Warn("authentication is disabled; every caller may call every RPC")
+	}
+
+	serverOpts := []grpc.ServerOption{
+		tracing.ServerOption(),
+		grpc.ChainUnaryInterceptor(unary...),
+		grpc.ChainStreamInterceptor(stream...),
+	}
+	if err := cfg.TLS.Validate(); err != nil {
+		return fmt.Errorf("invalid TLS configuration: %w", err)
+	}
+	if cfg.TLS.Enabled() {
+		reloader, err := certs.NewReloader(cfg.TLS)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificates: %w", err)
+		}
+		go reloader.Run(context.Background(), 10*time.Second)
+		serverOpts = append(serverOpts, grpc.Creds(credentials.NewTLS(reloader.TLSConfig())))
+	} else {
+		logger.Warn("TLS is disabled; serving plaintext gRPC")
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
+	pb.RegisterFulfillmentServiceServer(grpcServer, service)
+
+	logger.Info("fulfillment service is running", "addr", lis.Addr().String(), "tls", cfg.TLS.Enabled())
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			serveErr <- fmt.Errorf("failed to serve: %w", err)
+		}
+	}()
+
+	err = <-serveErr
+	grpcServer.Stop()
+	return err
 }
@@ -1,7 +1,16 @@
 package config
 
 import (
+	"fullfillment-service/internal/auth"
+	"fullfillment-service/internal/certs"
+	"fullfillment-service/internal/fulfillment"
+	"fullfillment-service/internal/logging"
+	"fullfillment-service/internal/tracing"
 	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -9,6 +18,99 @@ import (
 
 var db *gorm.DB
 
+// Config is the service configuration, read from the environment with
+// sensible defaults for local development.
+type Config struct {
+	Fulfillment fulfillment.Config
+	Tracing     tracing.Config
+	Logging     logging.Config
+	Auth        auth.Config
+	TLS         certs.Config
+
+	// EventBus selects where domain events are published: "log" or "nats".
+	EventBus          string
+	NATSURL           string
+	NATSSubjectPrefix string
+
+	// OrderSource selects where order-created events are consumed from:
+	// "" for nowhere, "nats" or "postgres". NATS events that fail are
+	// retried from the Postgres inbox.
+	OrderSource      string
+	NATSOrderSubject string
+	NATSOrderQueue   string
+
+	// MetricsAddr is the address the Prometheus /metrics endpoint listens
+	// on; empty disables it.
+	MetricsAddr string
+}
+
+func Load() Config {
+	cfg := Config{
+		Fulfillment:       fulfillment.DefaultConfig(),
+		Tracing:           tracing.DefaultConfig(),
+		Logging:           logging.DefaultConfig(),
+		EventBus:          envString("EVENT_BUS", "log"),
+		NATSURL:           envString("NATS_URL", "nats://127.0.0.1:4222"),
+		NATSSubjectPrefix: envString("NATS_SUBJECT_PREFIX", "fulfillment.events.v1"),
+		OrderSource:       envString("ORDER_SOURCE", ""),
+		NATSOrderSubject:  envString("NATS_ORDER_SUBJECT", "orders.events.v1.OrderCreated"),
+		NATSOrderQueue:    envString("NATS_ORDER_QUEUE", "fulfillment"),
+		MetricsAddr:       envString("METRICS_ADDR", ":9090"),
+	}
+	cfg.Fulfillment.OfferTimeout = envDuration("OFFER_TIMEOUT", cfg.Fulfillment.OfferTimeout)
+	cfg.Fulfillment.BatchWindow = envDuration("BATCH_WINDOW", cfg.Fulfillment.BatchWindow)
+	cfg.Fulfillment.BatchPickupRadiusMeters = envFloat("BATCH_PICKUP_RADIUS_METERS", cfg.Fulfillment.BatchPickupRadiusMeters)
+	cfg.Fulfillment.MaxDetourMeters = envFloat("MAX_DETOUR_METERS", cfg.Fulfillment.MaxDetourMeters)
+	cfg.Fulfillment.DispatchRingsMeters = envFloatList("DISPATCH_RINGS_METERS", cfg.Fulfillment.DispatchRingsMeters)
+	cfg.Fulfillment.MaxDispatchRadiusMeters = envFloat("MAX_DISPATCH_RADIUS_METERS", cfg.Fulfillment.MaxDispatchRadiusMeters)
+	cfg.Fulfillment.DefaultSpeedKmh = envFloat("DEFAULT_SPEED_KMH", cfg.Fulfillment.DefaultSpeedKmh)
+	cfg.Fulfillment.SpeedProfilesKmh = upperKeys(envFloatMap("SPEED_PROFILES_KMH", cfg.Fulfillment.SpeedProfilesKmh))
+	cfg.Fulfillment.CongestionFactors = envFloatMap("CONGESTION_FACTORS", cfg.Fulfillment.CongestionFactors)
+	cfg.Fulfillment.RoadDistanceFactor = envFloat("ROAD_DISTANCE_FACTOR", cfg.Fulfillment.RoadDistanceFactor)
+	cfg.Fulfillment.StopDwell = envDuration("STOP_DWELL", cfg.Fulfillment.StopDwell)
+	cfg.Fulfillment.StaleAfter = envDuration("DRIVER_STALE_AFTER", cfg.Fulfillment.StaleAfter)
+	cfg.Fulfillment.OfflineAfter = envDuration("DRIVER_OFFLINE_AFTER", cfg.Fulfillment.OfflineAfter)
+	cfg.Fulfillment.MaxHandoffDistanceMeters = envFloat("MAX_HANDOFF_DISTANCE_METERS", cfg.Fulfillment.MaxHandoffDistanceMeters)
+	cfg.Fulfillment.MaxPinAttempts = envInt("MAX_PIN_ATTEMPTS", cfg.Fulfillment.MaxPinAttempts)
+	cfg.Fulfillment.RetryableExceptions = envStringList("RETRYABLE_EXCEPTIONS", cfg.Fulfillment.RetryableExceptions)
+	cfg.Fulfillment.DeliveryRetryWait = envDuration("DELIVERY_RETRY_WAIT", cfg.Fulfillment.DeliveryRetryWait)
+	cfg.Fulfillment.MaxDeliveryAttempts = envInt("MAX_DELIVERY_ATTEMPTS", cfg.Fulfillment.MaxDeliveryAttempts)
+	cfg.Fulfillment.ScheduleLeadTime = envDuration("SCHEDULE_LEAD_TIME", cfg.Fulfillment.ScheduleLeadTime)
+	cfg.Fulfillment.SLATargets = envDurationMap("SLA_TARGETS", cfg.Fulfillment.SLATargets)
+	cfg.Fulfillment.SLAAtRiskRatio = envFloat("SLA_AT_RISK_RATIO", cfg.Fulfillment.SLAAtRiskRatio)
+	cfg.Fulfillment.OutboxBatchSize = envInt("OUTBOX_BATCH_SIZE", cfg.Fulfillment.OutboxBatchSize)
+	cfg.Fulfillment.OutboxMaxAttempts = envInt("OUTBOX_MAX_ATTEMPTS", cfg.Fulfillment.OutboxMaxAttempts)
+	cfg.Fulfillment.OutboxRetryBackoff = envDuration("OUTBOX_RETRY_BACKOFF", cfg.Fulfillment.OutboxRetryBackoff)
+	cfg.Fulfillment.OutboxMaxBackoff = envDuration("OUTBOX_MAX_BACKOFF", cfg.Fulfillment.OutboxMaxBackoff)
+	cfg.Fulfillment.WebhookBatchSize = envInt("WEBHOOK_BATCH_SIZE", cfg.Fulfillment.WebhookBatchSize)
+	cfg.Fulfillment.WebhookTimeout = envDuration("WEBHOOK_TIMEOUT", cfg.Fulfillment.WebhookTimeout)
+	cfg.Fulfillment.WebhookMaxAttempts = envInt("WEBHOOK_MAX_ATTEMPTS", cfg.Fulfillment.WebhookMaxAttempts)
+	cfg.Fulfillment.WebhookRetryBackoff = envDuration("WEBHOOK_RETRY_BACKOFF", cfg.Fulfillment.WebhookRetryBackoff)
+	cfg.Fulfillment.WebhookMaxBackoff = envDuration("WEBHOOK_MAX_BACKOFF", cfg.Fulfillment.WebhookMaxBackoff)
+	cfg.Fulfillment.WebhookDisableAfter = envInt("WEBHOOK_DISABLE_AFTER", cfg.Fulfillment.WebhookDisableAfter)
+	cfg.Fulfillment.WebhookAllowInsecure = envBool("WEBHOOK_ALLOW_INSECURE", cfg.Fulfillment.WebhookAllowInsecure)
+	cfg.Fulfillment.InboxBatchSize = envInt("INBOX_BATCH_SIZE", cfg.Fulfillment.InboxBatchSize)
+	cfg.Fulfillment.InboxMaxAttempts = envInt("INBOX_MAX_ATTEMPTS", cfg.Fulfillment.InboxMaxAttempts)
+	cfg.Fulfillment.InboxRetryBackoff = envDuration("INBOX_RETRY_BACKOFF", cfg.Fulfillment.InboxRetryBackoff)
+	cfg.Fulfillment.InboxMaxBackoff = envDuration("INBOX_MAX_BACKOFF", cfg.Fulfillment.InboxMaxBackoff)
+	cfg.Logging.Level = envString("LOG_LEVEL", cfg.Logging.Level)
+	cfg.Logging.Format = envString("LOG_FORMAT", cfg.Logging.Format)
+	cfg.Tracing.Exporter = envString("TRACE_EXPORTER", cfg.Tracing.Exporter)
+	cfg.Tracing.OTLPEndpoint = envString("OTLP_ENDPOINT", cfg.Tracing.OTLPEndpoint)
+	cfg.Tracing.OTLPInsecure = envBool("OTLP_INSECURE", cfg.Tracing.OTLPInsecure)
+	cfg.Tracing.ServiceName = envString("TRACE_SERVICE_NAME", cfg.Tracing.ServiceName)
+	cfg.Tracing.SampleRatio = envFloat("TRACE_SAMPLE_RATIO", cfg.Tracing.SampleRatio)
+	cfg.Auth.Enabled = envBool("AUTH_ENABLED", cfg.Auth.Enabled)
+	cfg.Auth.JWKSFile = envString("AUTH_JWKS_FILE", cfg.Auth.JWKSFile)
+	cfg.Auth.Issuer = envString("AUTH_ISSUER", cfg.Auth.Issuer)
+	cfg.Auth.Audience = envString("AUTH_AUDIENCE", cfg.Auth.Audience)
+	cfg.TLS.CertFile = envString("TLS_CERT_FILE", cfg.TLS.CertFile)
+	cfg.TLS.KeyFile = envString("TLS_KEY_FILE", cfg.TLS.KeyFile)
+	cfg.TLS.ClientCAFile = envString("TLS_CLIENT_CA_FILE", cfg.TLS.ClientCAFile)
+	cfg.TLS.RequireClientCert = envBool("TLS_REQUIRE_CLIENT_CERT", cfg.TLS.RequireClientCert)
+	return cfg
+}
+
 func InitDB() *gorm.DB {
 	dsn := "host=localhost user=postgres password=1234 dbname=fulfillmentdb port=5432 sslmode=disable"
 	var err error
@@ -18,3 +120,152 @@ func InitDB() *gorm.DB {
 	}
 	return db
 }
+
+func envString(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+func envDuration(key string, fallback time.Duration) time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return d
+}
+
+func envFloat(key string, fallback float64) float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return f
+}
+
+func envInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return n
+}
+
+func envBool(key string, fallback bool) bool {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("invalid %s: %v", key, err)
+	}
+	return b
+}
+
+// envStringList parses a comma separated list such as "a,b". An empty value
+// gives an empty list.
+func envStringList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// envFloatList parses a comma separated list such as "1000,3000".
+func envFloatList(key string, fallback []float64) []float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var result []float64
+	for _, item := range strings.Split(value, ",") {
+		f, err := strconv.ParseFloat(strings.TrimSpace(item), 64)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", key, err)
+		}
+		result = append(result, f)
+	}
+	return result
+}
+
+// envFloatMap parses comma separated key=value pairs such as
+// "BIKE=15,CAR=30". Keys that are not mentioned keep their fallback value.
+func envFloatMap(key string, fallback map[string]float64) map[string]float64 {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	result := make(map[string]float64, len(fallback))
+	for k, v := range fallback {
+		result[k] = v
+	}
+	for _, pair := range strings.Split(value, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			log.Fatalf("invalid %s: expected key=value, got %q", key, pair)
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", key, err)
+		}
+		result[strings.TrimSpace(k)] = f
+	}
+	return result
+}
+
+// upperKeys upper-cases the keys of m, so vehicle types may be given in any
+// case but match the stored ones.
+func upperKeys(m map[string]float64) map[string]float64 {
+	result := make(map[string]float64, len(m))
+	for k, v := range m {
+		result[strings.ToUpper(k)] = v
+	}
+	return result
+}
+
+// envDurationMap parses comma separated key=duration pairs such as
+// "EXPRESS=30m,STANDARD=1h". Keys that are not mentioned keep their fallback
+// value.
+func envDurationMap(key string, fallback map[string]time.Duration) map[string]time.Duration {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	result := make(map[string]time.Duration, len(fallback))
+	for k, v := range fallback {
+		result[k] = v
+	}
+	for _, pair := range strings.Split(value, ",") {
+		k, v, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			log.Fatalf("invalid %s: expected key=value, got %q", key, pair)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			log.Fatalf("invalid %s: %v", key, err)
+		}
+		result[strings.TrimSpace(k)] = d
+	}
+	return result
+}
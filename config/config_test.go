@@ -1,7 +1,9 @@
 package config
 
 import (
+	"fullfillment-service/internal/fulfillment"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"gorm.io/driver/postgres"
@@ -34,3 +36,55 @@ func TestInitDB(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %v", err)
 	}
 }
+
+func TestLoad(t *testing.T) {
+	t.Setenv("OFFER_TIMEOUT", "45s")
+	t.Setenv("MAX_DETOUR_METERS", "1500")
+	t.Setenv("SPEED_PROFILES_KMH", "bike=15, car=30")
+	t.Setenv("CONGESTION_FACTORS", "midtown=1.8")
+	t.Setenv("DISPATCH_RINGS_METERS", "500, 2000")
+	t.Setenv("SLA_TARGETS", "EXPRESS=25m")
+	t.Setenv("WEBHOOK_BATCH_SIZE", "25")
+	t.Setenv("TRACE_EXPORTER", "otlp")
+	t.Setenv("OTLP_INSECURE", "true")
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_ISSUER", "https://auth.example.com")
+	t.Setenv("TLS_CERT_FILE", "/etc/tls/tls.crt")
+	t.Setenv("TLS_REQUIRE_CLIENT_CERT", "true")
+
+	cfg := Load()
+
+	if cfg.Fulfillment.OfferTimeout != 45*time.Second {
+		t.Errorf("expected offer timeout 45s, got %v", cfg.Fulfillment.OfferTimeout)
+	}
+	if cfg.Fulfillment.MaxDetourMeters != 1500 {
+		t.Errorf("expected max detour 1500, got %v", cfg.Fulfillment.MaxDetourMeters)
+	}
+	if cfg.Fulfillment.SpeedProfilesKmh[fulfillment.VehicleBike] != 15 || cfg.Fulfillment.SpeedProfilesKmh[fulfillment.VehicleCar] != 30 {
+		t.Errorf("unexpected speed profiles %v", cfg.Fulfillment.SpeedProfilesKmh)
+	}
+	if cfg.Fulfillment.CongestionFactors["midtown"] != 1.8 {
+		t.Errorf("unexpected congestion factors %v", cfg.Fulfillment.CongestionFactors)
+	}
+	if len(cfg.Fulfillment.DispatchRingsMeters) != 2 || cfg.Fulfillment.DispatchRingsMeters[1] != 2000 {
+		t.Errorf("unexpected dispatch rings %v", cfg.Fulfillment.DispatchRingsMeters)
+	}
+	if cfg.Fulfillment.SLATargets["EXPRESS"] != 25*time.Minute || cfg.Fulfillment.SLATargets["STANDARD"] != time.Hour {
+		t.Errorf("unexpected SLA targets %v", cfg.Fulfillment.SLATargets)
+	}
+	if cfg.Fulfillment.WebhookBatchSize != 25 {
+		t.Errorf("expected webhook batch size 25, got %v", cfg.Fulfillment.WebhookBatchSize)
+	}
+	if cfg.Tracing.Exporter != "otlp" || !cfg.Tracing.OTLPInsecure || cfg.Tracing.OTLPEndpoint != "localhost:4317" {
+		t.Errorf("unexpected tracing config %+v", cfg.Tracing)
+	}
+	if !cfg.Auth.Enabled || cfg.Auth.Issuer != "https://auth.example.com" || cfg.Auth.Audience != "" {
+		t.Errorf("unexpected auth config %+v", cfg.Auth)
+	}
+	if cfg.TLS.CertFile != "/etc/tls/tls.crt" || !cfg.TLS.RequireClientCert || cfg.TLS.KeyFile != "" {
+		t.Errorf("unexpected TLS config %+v", cfg.TLS)
+	}
+	if cfg.Fulfillment.BatchWindow != 10*time.Minute {
+		t.Errorf("expected default batch window, got %v", cfg.Fulfillment.BatchWindow)
+	}
+}
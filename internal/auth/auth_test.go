@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+type testKeys struct {
+	rsa *rsa.PrivateKey
+	ec  *ecdsa.PrivateKey
+}
+
+func newTestKeys(t *testing.T) (testKeys, Keys) {
+	t.Helper()
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	set := map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa1", "use": "sig",
+			"n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec1", "crv": "P-256",
+			"x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "RSA", "kid": "enc1", "use": "enc"},
+	}}
+	data, err := json.Marshal(set)
+	require.NoError(t, err)
+
+	keys, err := ParseJWKS(data)
+	require.NoError(t, err)
+	return testKeys{rsa: rsaKey, ec: ecKey}, keys
+}
+
+func (k testKeys) sign(t *testing.T, method jwt.SigningMethod, kid string, c claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, c)
+	token.Header["kid"] = kid
+	var key interface{} = k.rsa
+	if kid == "ec1" {
+		key = k.ec
+	}
+	signed, err := token.SignedString(key)
+	require.NoError(t, err)
+	return signed
+}
+
+func bearer(token string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+}
+
+func TestParseJWKS(t *testing.T) {
+	_, keys := newTestKeys(t)
+	assert.Len(t, keys, 2, "encryption keys are skipped")
+	assert.ElementsMatch(t, []string{"RS256", "RS384", "RS512", "ES256", "ES384"}, keys.Algorithms())
+
+	_, err := ParseJWKS([]byte(`{"keys": []}`))
+	assert.Error(t, err)
+	_, err = ParseJWKS([]byte(`{"keys": [{"kty": "oct", "kid": "k"}]}`))
+	assert.Error(t, err)
+}
+
+func TestAuthenticateToken(t *testing.T) {
+	signer, keys := newTestKeys(t)
+	authenticator := NewAuthenticator(Config{Issuer: "https://auth.example.com", Audience: "fulfillment"}, keys)
+	valid := func(subject string, roles ...string) claims {
+		return claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   subject,
+				Issuer:    "https://auth.example.com",
+				Audience:  jwt.ClaimStrings{"fulfillment"},
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			Roles: roles,
+		}
+	}
+
+	t.Run("RSA Token", func(t *testing.T) {
+		token := signer.sign(t, jwt.SigningMethodRS256, "rsa1", valid("alice", RoleOps))
+
+		identity, err := authenticator.Authenticate(bearer(token))
+		require.NoError(t, err)
+		assert.Equal(t, &Identity{Subject: "alice", Roles: []string{RoleOps}}, identity)
+	})
+
+	t.Run("EC Token For Driver", func(t *testing.T) {
+		c := valid("user-7", RoleDriver)
+		c.DeliveryPersonID = "dp1"
+		token := signer.sign(t, jwt.SigningMethodES256, "ec1", c)
+
+		identity, err := authenticator.Authenticate(bearer(token))
+		require.NoError(t, err)
+		assert.Equal(t, "dp1", identity.DeliveryPersonID)
+	})
+
+	t.Run("Driver Defaults To Subject", func(t *testing.T) {
+		token := signer.sign(t, jwt.SigningMethodRS256, "rsa1", valid("dp2", RoleDriver))
+
+		identity, err := authenticator.Authenticate(bearer(token))
+		require.NoError(t, err)
+		assert.Equal(t, "dp2", identity.DeliveryPersonID)
+	})
+
+	rejected := map[string]func() string{
+		"Expired": func() string {
+			c := valid("alice", RoleOps)
+			c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+			return signer.sign(t, jwt.SigningMethodRS256, "rsa1", c)
+		},
+		"No Expiry": func() string {
+			c := valid("alice", RoleOps)
+			c.ExpiresAt = nil
+			return signer.sign(t, jwt.SigningMethodRS256, "rsa1", c)
+		},
+		"Wrong Issuer": func() string {
+			c := valid("alice", RoleOps)
+			c.Issuer = "https://evil.example.com"
+			return signer.sign(t, jwt.SigningMethodRS256, "rsa1", c)
+		},
+		"Wrong Audience": func() string {
+			c := valid("alice", RoleOps)
+			c.Audience = jwt.ClaimStrings{"billing"}
+			return signer.sign(t, jwt.SigningMethodRS256, "rsa1", c)
+		},
+		"Unknown Key": func() string {
+			return signer.sign(t, jwt.SigningMethodRS256, "rsa2", valid("alice", RoleOps))
+		},
+		"Unsigned": func() string {
+			token, err := jwt.NewWithClaims(jwt.SigningMethodNone, valid("alice", RoleOps)).
+				SignedString(jwt.UnsafeAllowNoneSignatureType)
+			require.NoError(t, err)
+			return token
+		},
+	}
+	for name, token := range rejected {
+		t.Run(name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(bearer(token()))
+			assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		})
+	}
+
+	t.Run("Not A Bearer Token", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic YWxpY2U6"))
+		_, err := authenticator.Authenticate(ctx)
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("No Credentials", func(t *testing.T) {
+		_, err := authenticator.Authenticate(context.Background())
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
+
+func TestAuthenticateCertificate(t *testing.T) {
+	_, keys := newTestKeys(t)
+	authenticator := NewAuthenticator(Config{}, keys)
+	cert := &x509.Certificate{Subject: pkix.Name{CommonName: "dp1", OrganizationalUnit: []string{RoleDriver}}}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}},
+	}})
+
+	identity, err := authenticator.Authenticate(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "dp1", Roles: []string{RoleDriver}, DeliveryPersonID: "dp1"}, identity)
+
+	unverified := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: credentials.TLSInfo{
+		State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{cert}},
+	}})
+	_, err = authenticator.Authenticate(unverified)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Policy lists the roles allowed to call each method. Methods missing from
+// the policy are denied.
+type Policy map[string][]string
+
+// service prefixes the full method names of the fulfillment service.
+const service = "/proto.FulfillmentService/"
+
+var (
+	drivers  = []string{RoleOps, RoleDriver}
+	staff    = []string{RoleOps, RoleCustomerService}
+	readers  = []string{RoleOps, RoleCustomerService, RoleInternalService}
+	opsOnly  = []string{RoleOps}
+	services = []string{RoleOps, RoleInternalService}
+)
+
+// DefaultPolicy is the access policy of the fulfillment service. Drivers
+// may only act on their own behalf; see DeliveryPersonScope.
+var DefaultPolicy = Policy{
+	service + "AssignOrder":                  services,
+	service + "GetOrderStatus":               readers,
+	service + "UpdateOrderStatus":            drivers,
+	service + "GetOrdersByDeliveryPerson":    {RoleOps, RoleCustomerService, RoleDriver},
+	service + "AcceptOffer":                  drivers,
+	service + "DeclineOffer":                 drivers,
+	service + "GetDriverRoute":               {RoleOps, RoleCustomerService, RoleDriver},
+	service + "UpdateDeliveryPersonLocation": drivers,
+	service + "CreateZone":                   opsOnly,
+	service + "GetZone":                      readers,
+	service + "ListZones":                    readers,
+	service + "UpdateZone":                   opsOnly,
+	service + "DeleteZone":                   opsOnly,
+	service + "StartShift":                   drivers,
+	service + "EndShift":                     drivers,
+	service + "Heartbeat":                    drivers,
+	service + "CompleteDelivery":             drivers,
+	service + "GetProofOfDelivery":           staff,
+	service + "GetDeliveryPin":               {RoleOps, RoleCustomerService, RoleInternalService},
+	service + "VerifyDeliveryPin":            drivers,
+	service + "OverrideDeliveryPin":          opsOnly,
+	service + "ReportDeliveryException":      drivers,
+	service + "CompleteReturn":               drivers,
+	service + "SetSlaTarget":                 opsOnly,
+	service + "ListSlaTargets":               staff,
+	service + "CreateWebhookSubscription":    opsOnly,
+	service + "ListWebhookSubscriptions":     opsOnly,
+	service + "DeleteWebhookSubscription":    opsOnly,
+	service + "EnableWebhookSubscription":    opsOnly,
+	service + "ListWebhookDeliveries":        opsOnly,
+}
+
+// authorize checks identity may call method. It returns the delivery person
+// the caller is limited to if only their driver role allows the call.
+func (p Policy) authorize(identity *Identity, method string) (string, error) {
+	allowed := false
+	for _, role := range p[method] {
+		if !identity.HasRole(role) {
+			continue
+		}
+		if role != RoleDriver {
+			return "", nil
+		}
+		allowed = true
+	}
+	if !allowed {
+		return "", status.Errorf(codes.PermissionDenied, "%s may not call %s", identity.Subject, method)
+	}
+	if identity.DeliveryPersonID == "" {
+		return "", status.Error(codes.PermissionDenied, "driver identity has no delivery person ID")
+	}
+	return identity.DeliveryPersonID, nil
+}
+
+// UnaryServerInterceptor authenticates and authorizes every unary RPC and
+// makes the caller available through FromContext. When the caller acts as a
+// driver, a delivery person ID in the request must be their own.
+func UnaryServerInterceptor(authenticator *Authenticator, policy Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, scope, err := check(ctx, authenticator, policy, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		if r, ok := req.(interface{ GetDeliveryPersonId() string }); ok && scope != "" && r.GetDeliveryPersonId() != scope {
+			return nil, status.Error(codes.PermissionDenied, "drivers may only act for themselves")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs.
+// Handlers must check DeliveryPersonScope against each message themselves.
+func StreamServerInterceptor(authenticator *Authenticator, policy Policy) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, _, err := check(ss.Context(), authenticator, policy, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func check(ctx context.Context, authenticator *Authenticator, policy Policy, method string) (context.Context, string, error) {
+	identity, err := authenticator.Authenticate(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	scope, err := policy.authorize(identity, method)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx = WithIdentity(ctx, identity)
+	if scope != "" {
+		ctx = WithDeliveryPersonScope(ctx, scope)
+	}
+	return ctx, scope, nil
+}
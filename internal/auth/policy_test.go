@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	signer, keys := newTestKeys(t)
+	interceptor := UnaryServerInterceptor(NewAuthenticator(Config{}, keys), DefaultPolicy)
+	token := func(subject string, roles ...string) context.Context {
+		return bearer(signer.sign(t, jwt.SigningMethodRS256, "rsa1", claims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Subject:   subject,
+				ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+			},
+			Roles: roles,
+		}))
+	}
+	call := func(ctx context.Context, method string, req interface{}) (context.Context, error) {
+		var handled context.Context
+		_, err := interceptor(ctx, req, &grpc.UnaryServerInfo{FullMethod: service + method},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				handled = ctx
+				return nil, nil
+			})
+		return handled, err
+	}
+
+	t.Run("Allows Role", func(t *testing.T) {
+		ctx, err := call(token("alice", RoleOps), "CreateZone", &pb.CreateZoneRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, "alice", FromContext(ctx).Subject)
+		_, scoped := DeliveryPersonScope(ctx)
+		assert.False(t, scoped)
+	})
+
+	t.Run("Denies Role", func(t *testing.T) {
+		_, err := call(token("bob", RoleCustomerService), "CreateZone", &pb.CreateZoneRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Denies Drivers The Delivery PIN", func(t *testing.T) {
+		_, err := call(token("dp1", RoleDriver), "GetDeliveryPin", &pb.GetDeliveryPinRequest{OrderId: "order1"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Denies Unknown Method", func(t *testing.T) {
+		_, err := call(token("alice", RoleOps), "DropAllOrders", &pb.GetOrderStatusRequest{})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Requires Credentials", func(t *testing.T) {
+		_, err := call(context.Background(), "GetOrderStatus", &pb.GetOrderStatusRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Scopes Drivers", func(t *testing.T) {
+		ctx, err := call(token("dp1", RoleDriver), "UpdateOrderStatus", &pb.UpdateOrderStatusRequest{OrderId: "order1"})
+		require.NoError(t, err)
+		scope, ok := DeliveryPersonScope(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "dp1", scope)
+	})
+
+	t.Run("Drivers Act For Themselves", func(t *testing.T) {
+		_, err := call(token("dp1", RoleDriver), "Heartbeat", &pb.HeartbeatRequest{DeliveryPersonId: "dp1"})
+		require.NoError(t, err)
+
+		_, err = call(token("dp1", RoleDriver), "Heartbeat", &pb.HeartbeatRequest{DeliveryPersonId: "dp2"})
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Ops Are Not Scoped", func(t *testing.T) {
+		ctx, err := call(token("alice", RoleOps, RoleDriver), "Heartbeat", &pb.HeartbeatRequest{DeliveryPersonId: "dp2"})
+		require.NoError(t, err)
+		_, scoped := DeliveryPersonScope(ctx)
+		assert.False(t, scoped)
+	})
+}
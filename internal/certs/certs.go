@@ -0,0 +1,192 @@
+// Package certs serves TLS for the gRPC listener from certificate files,
+// optionally verifying client certificates, and picks up rotated files
+// without a restart.
+package certs
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"fullfillment-service/internal/logging"
+)
+
+// Config names the PEM files TLS is served from. TLS is off unless CertFile
+// is set. With ClientCAFile, client certificates are verified against that
+// bundle when presented, and required when RequireClientCert is set.
+type Config struct {
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string
+	RequireClientCert bool
+}
+
+func (c Config) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// Validate rejects a partial configuration, such as a client CA without a
+// certificate, which would otherwise quietly serve plaintext.
+func (c Config) Validate() error {
+	if c.CertFile == "" {
+		if c.KeyFile != "" || c.ClientCAFile != "" || c.RequireClientCert {
+			return fmt.Errorf("TLS settings are given but the TLS certificate file is not set")
+		}
+		return nil
+	}
+	if c.KeyFile == "" {
+		return fmt.Errorf("TLS key file is not set")
+	}
+	if c.RequireClientCert && c.ClientCAFile == "" {
+		return fmt.Errorf("client certificates are required but no client CA file is set")
+	}
+	return nil
+}
+
+// Reloader holds the TLS configuration built from the files in a Config and
+// rebuilds it when they change.
+type Reloader struct {
+	cfg Config
+
+	mu      sync.RWMutex
+	current *tls.Config
+	stamps  map[string]stamp
+}
+
+// stamp identifies a version of a file.
+type stamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewReloader loads the files in cfg. It fails if any of them is missing or
+// invalid, so a misconfigured server does not start.
+func NewReloader(cfg Config) (*Reloader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled() {
+		return nil, fmt.Errorf("TLS certificate file is not set")
+	}
+	r := &Reloader{cfg: cfg}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// TLSConfig is the server configuration to listen with. Every handshake uses
+// the most recently loaded certificate and client CAs.
+func (r *Reloader) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.current, nil
+		},
+	}
+}
+
+// Reload reads the files again. On error the previous configuration stays in
+// use.
+func (r *Reloader) Reload() error {
+	stamps, err := r.stat()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2"},
+	}
+
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates in %s", r.cfg.ClientCAFile)
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.RequireClientCert {
+			config.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.current = config
+	r.stamps = stamps
+	return nil
+}
+
+// changed reports whether any of the files differs from what was loaded.
+func (r *Reloader) changed() (bool, error) {
+	stamps, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for path, s := range stamps {
+		if r.stamps[path] != s {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *Reloader) stat() (map[string]stamp, error) {
+	stamps := make(map[string]stamp)
+	for _, path := range []string{r.cfg.CertFile, r.cfg.KeyFile, r.cfg.ClientCAFile} {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		stamps[path] = stamp{modTime: info.ModTime(), size: info.Size()}
+	}
+	return stamps, nil
+}
+
+// Run checks the files every interval and reloads them once they change,
+// until ctx is cancelled. A half-written rotation fails to load and is
+// retried on the next check.
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.changed()
+			if err == nil && changed {
+				err = r.Reload()
+				if err == nil {
+					logging.FromContext(ctx).Info("reloaded TLS certificates", "cert_file", r.cfg.CertFile)
+				}
+			}
+			if err != nil {
+				logging.FromContext(ctx).Error("failed to reload TLS certificates", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package certs
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a PEM certificate and key for a server on localhost or, with
+// client set, for a client.
+func (a *authority) issue(t *testing.T, serial int64, client bool) ([]byte, []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	if client {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, data []byte, modTime time.Time) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// serve starts a gRPC health server with the reloader's TLS configuration
+// and returns its address.
+func serve(t *testing.T, r *Reloader) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(r.TLSConfig())))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+	return lis.Addr().String()
+}
+
+// check calls the health service and returns the certificate the server
+// presented.
+func check(addr string, config *tls.Config) (*x509.Certificate, error) {
+	var served *x509.Certificate
+	config.VerifyConnection = func(state tls.ConnectionState) error {
+		served = state.PeerCertificates[0]
+		return nil
+	}
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(credentials.NewTLS(config)))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	return served, err
+}
+
+func TestReloader(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	cfg := Config{
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		ClientCAFile: filepath.Join(dir, "ca.crt"),
+	}
+	loaded := time.Now().Add(-time.Minute)
+	cert, key := ca.issue(t, 2, false)
+	writeFile(t, cfg.CertFile, cert, loaded)
+	writeFile(t, cfg.KeyFile, key, loaded)
+	writeFile(t, cfg.ClientCAFile, ca.pem, loaded)
+
+	r, err := NewReloader(cfg)
+	require.NoError(t, err)
+	addr := serve(t, r)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	t.Run("Serves Certificate", func(t *testing.T) {
+		served, err := check(addr, &tls.Config{RootCAs: roots})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), served.SerialNumber.Int64())
+	})
+
+	t.Run("Verifies Client Certificate", func(t *testing.T) {
+		clientCert, clientKey := ca.issue(t, 3, true)
+		pair, err := tls.X509KeyPair(clientCert, clientKey)
+		require.NoError(t, err)
+		_, err = check(addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}})
+		require.NoError(t, err)
+
+		otherCert, otherKey := newAuthority(t).issue(t, 4, true)
+		pair, err = tls.X509KeyPair(otherCert, otherKey)
+		require.NoError(t, err)
+		_, err = check(addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}})
+		assert.Error(t, err)
+	})
+
+	t.Run("Reloads Changed Files", func(t *testing.T) {
+		changed, err := r.changed()
+		require.NoError(t, err)
+		assert.False(t, changed)
+
+		rotated := time.Now()
+		cert, key := ca.issue(t, 5, false)
+		writeFile(t, cfg.CertFile, cert, rotated)
+
+		changed, err = r.changed()
+		require.NoError(t, err)
+		require.True(t, changed)
+		assert.Error(t, r.Reload(), "the new certificate does not match the old key")
+		served, err := check(addr, &tls.Config{RootCAs: roots})
+		require.NoError(t, err)
+		assert.Equal(t, int64(2), served.SerialNumber.Int64())
+
+		writeFile(t, cfg.KeyFile, key, rotated)
+		require.NoError(t, r.Reload())
+		served, err = check(addr, &tls.Config{RootCAs: roots})
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), served.SerialNumber.Int64())
+
+		changed, err = r.changed()
+		require.NoError(t, err)
+		assert.False(t, changed)
+	})
+}
+
+func TestRequireClientCert(t *testing.T) {
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	cfg := Config{
+		CertFile:          filepath.Join(dir, "tls.crt"),
+		KeyFile:           filepath.Join(dir, "tls.key"),
+		ClientCAFile:      filepath.Join(dir, "ca.crt"),
+		RequireClientCert: true,
+	}
+	cert, key := ca.issue(t, 2, false)
+	writeFile(t, cfg.CertFile, cert, time.Now())
+	writeFile(t, cfg.KeyFile, key, time.Now())
+	writeFile(t, cfg.ClientCAFile, ca.pem, time.Now())
+
+	r, err := NewReloader(cfg)
+	require.NoError(t, err)
+	addr := serve(t, r)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	_, err = check(addr, &tls.Config{RootCAs: roots})
+	assert.Error(t, err)
+
+	clientCert, clientKey := ca.issue(t, 3, true)
+	pair, err := tls.X509KeyPair(clientCert, clientKey)
+	require.NoError(t, err)
+	_, err = check(addr, &tls.Config{RootCAs: roots, Certificates: []tls.Certificate{pair}})
+	assert.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Config{}.Validate())
+	assert.NoError(t, Config{CertFile: "tls.crt", KeyFile: "tls.key"}.Validate())
+	assert.NoError(t, Config{CertFile: "tls.crt", KeyFile: "tls.key", ClientCAFile: "ca.crt", RequireClientCert: true}.Validate())
+
+	for _, cfg := range []Config{
+		{KeyFile: "tls.key"},
+		{ClientCAFile: "ca.crt"},
+		{RequireClientCert: true},
+	} {
+		assert.EqualError(t, cfg.Validate(), "TLS settings are given but the TLS certificate file is not set", "%+v", cfg)
+	}
+	assert.EqualError(t, Config{CertFile: "tls.crt"}.Validate(), "TLS key file is not set")
+}
+
+func TestNewReloaderErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := NewReloader(Config{CertFile: filepath.Join(dir, "tls.crt")})
+	assert.EqualError(t, err, "TLS key file is not set")
+
+	_, err = NewReloader(Config{CertFile: "tls.crt", KeyFile: "tls.key", RequireClientCert: true})
+	assert.EqualError(t, err, "client certificates are required but no client CA file is set")
+
+	_, err = NewReloader(Config{CertFile: filepath.Join(dir, "tls.crt"), KeyFile: filepath.Join(dir, "tls.key")})
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"fullfillment-service/internal/fulfillment"
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultOrderCreatedSubject is the subject the order service publishes new
+// orders on unless configured otherwise.
+const DefaultOrderCreatedSubject = "orders.events.v1.OrderCreated"
+
+// Messages a consumer gives up on are republished to its subject plus
+// DeadLetterSuffix, with the reason in the ErrorHeader header.
+const (
+	DeadLetterSuffix = ".dead"
+	ErrorHeader      = "Error"
+)
+
+// Handler processes one inbound event. Errors wrapping
+// fulfillment.ErrPoisonMessage are not retried.
+type Handler func(ctx context.Context, event *pb.Event) error
+
+// Requeuer hands an event that failed for a reason other than being poison
+// to durable storage, to be retried later with backoff.
+type Requeuer func(ctx context.Context, event *pb.Event, cause error) error
+
+// NATSConsumer feeds protobuf events from a NATS subject to a handler. A
+// message that fails is not retried in the subscription callback, which
+// would hold up every message behind it: it is passed to requeue instead. A
+// poison message, or one that cannot be requeued, is moved to the
+// dead-letter subject.
+type NATSConsumer struct {
+	conn    *nats.Conn
+	subject string
+	handle  Handler
+	requeue Requeuer
+}
+
+func NewNATSConsumer(conn *nats.Conn, subject string, handle Handler, requeue Requeuer) *NATSConsumer {
+	if subject == "" {
+		subject = DefaultOrderCreatedSubject
+	}
+	return &NATSConsumer{conn: conn, subject: subject, handle: handle, requeue: requeue}
+}
+
+// Subscribe starts consuming as a member of queue, so that replicas of the
+// service share the messages between them. Handlers run with ctx.
+func (c *NATSConsumer) Subscribe(ctx context.Context, queue string) (*nats.Subscription, error) {
+	return c.conn.QueueSubscribe(c.subject, queue, func(msg *nats.Msg) {
+		c.consume(ctx, msg)
+	})
+}
+
+func (c *NATSConsumer) consume(ctx context.Context, msg *nats.Msg) {
+	event := &pb.Event{}
+	if err := proto.Unmarshal(msg.Data, event); err != nil {
+		c.deadLetter(ctx, msg, fmt.Errorf("%w: %v", fulfillment.ErrPoisonMessage, err))
+		return
+	}
+
+	err := c.handle(ctx, event)
+	if err == nil {
+		return
+	}
+	if errors.Is(err, fulfillment.ErrPoisonMessage) {
+		c.deadLetter(ctx, msg, err)
+		return
+	}
+	if requeueErr := c.requeue(ctx, event, err); requeueErr != nil {
+		c.deadLetter(ctx, msg, fmt.Errorf("%v; requeue failed: %w", err, requeueErr))
+	}
+}
+
+func (c *NATSConsumer) deadLetter(ctx context.Context, msg *nats.Msg, cause error) {
+	logger := logging.FromContext(ctx).With("subject", msg.Subject)
+	logger.Warn("giving up on message", "error", cause)
+
+	dead := nats.NewMsg(c.subject + DeadLetterSuffix)
+	dead.Data = msg.Data
+	for key, values := range msg.Header {
+		dead.Header[key] = values
+	}
+	dead.Header.Set(ErrorHeader, cause.Error())
+	if err := c.conn.PublishMsg(dead); err != nil {
+		logger.Error("failed to dead-letter message", "error", err)
+	}
+}
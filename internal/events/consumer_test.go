@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"fullfillment-service/internal/fulfillment"
+	pb "fullfillment-service/proto"
+
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestNATSConsumer(t *testing.T) {
+	ns := runServer(t)
+
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	dead, err := conn.SubscribeSync(DefaultOrderCreatedSubject + DeadLetterSuffix)
+	require.NoError(t, err)
+
+	handled := make(chan string, 10)
+	requeued := make(chan string, 10)
+	consumer := NewNATSConsumer(conn, "", func(ctx context.Context, event *pb.Event) error {
+		switch event.GetOrderCreated().GetOrderId() {
+		case "flaky", "stuck":
+			return errors.New("database unavailable")
+		case "invalid":
+			return fmt.Errorf("%w: unknown package size", fulfillment.ErrPoisonMessage)
+		}
+		handled <- event.GetOrderCreated().GetOrderId()
+		return nil
+	}, func(ctx context.Context, event *pb.Event, cause error) error {
+		if event.GetOrderCreated().GetOrderId() == "stuck" {
+			return errors.New("inbox unavailable")
+		}
+		requeued <- event.GetOrderCreated().GetOrderId() + ": " + cause.Error()
+		return nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = consumer.Subscribe(ctx, "fulfillment")
+	require.NoError(t, err)
+
+	publish := func(orderID string) {
+		data, err := proto.Marshal(&pb.Event{EventId: orderID, Payload: &pb.Event_OrderCreated{OrderCreated: &pb.OrderCreated{OrderId: orderID}}})
+		require.NoError(t, err)
+		require.NoError(t, conn.Publish(DefaultOrderCreatedSubject, data))
+	}
+
+	t.Run("Success - Handled", func(t *testing.T) {
+		publish("order1")
+
+		select {
+		case orderID := <-handled:
+			assert.Equal(t, "order1", orderID)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not handled")
+		}
+	})
+
+	t.Run("Success - Failure Is Requeued", func(t *testing.T) {
+		publish("flaky")
+
+		select {
+		case requeue := <-requeued:
+			assert.Equal(t, "flaky: database unavailable", requeue)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not requeued")
+		}
+	})
+
+	t.Run("Failure - Unrequeueable Is Dead-Lettered", func(t *testing.T) {
+		publish("stuck")
+
+		msg, err := dead.NextMsg(5 * time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, msg.Header.Get(ErrorHeader), "inbox unavailable")
+	})
+
+	t.Run("Failure - Poison Is Dead-Lettered", func(t *testing.T) {
+		publish("invalid")
+
+		msg, err := dead.NextMsg(5 * time.Second)
+		require.NoError(t, err)
+		assert.Contains(t, msg.Header.Get(ErrorHeader), "unknown package size")
+	})
+
+	t.Run("Failure - Undecodable Is Dead-Lettered", func(t *testing.T) {
+		require.NoError(t, conn.Publish(DefaultOrderCreatedSubject, []byte("not protobuf")))
+
+		msg, err := dead.NextMsg(5 * time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, []byte("not protobuf"), msg.Data)
+	})
+}
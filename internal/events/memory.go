@@ -0,0 +1,37 @@
+// Package events connects the fulfillment service to other services over a
+// message bus: publishers send its domain events out and consumers feed
+// upstream events in.
+package events
+
+import (
+	"context"
+	"sync"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// MemoryPublisher keeps published events in memory, for tests.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []*pb.Event
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, proto.Clone(event).(*pb.Event))
+	return nil
+}
+
+// Events returns the events published so far, oldest first.
+func (p *MemoryPublisher) Events() []*pb.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*pb.Event(nil), p.events...)
+}
@@ -0,0 +1,67 @@
+package events
+
+import (
+	"context"
+	"strconv"
+
+	pb "fullfillment-service/proto"
+
+	"github.com/nats-io/nats.go"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultSubjectPrefix is the subject prefix events are published under
+// unless configured otherwise.
+const DefaultSubjectPrefix = "fulfillment.events.v1"
+
+// SchemaVersionHeader carries the event's schema version so subscribers can
+// tell versions apart without decoding the message.
+const SchemaVersionHeader = "Schema-Version"
+
+// Subject is the subject an event is published on: the prefix followed by
+// the name of its payload message, e.g. "fulfillment.events.v1.OrderAssigned".
+func Subject(prefix string, event *pb.Event) string {
+	message := event.ProtoReflect()
+	field := message.WhichOneof(message.Descriptor().Oneofs().ByName("payload"))
+	if field == nil {
+		return prefix + ".Unknown"
+	}
+	return prefix + "." + string(field.Message().Name())
+}
+
+// NATSPublisher publishes events to NATS as protobuf messages. The event ID
+// is sent as the Nats-Msg-Id header, so JetStream streams drop the
+// duplicates that at-least-once delivery produces.
+type NATSPublisher struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+func NewNATSPublisher(conn *nats.Conn, prefix string) *NATSPublisher {
+	if prefix == "" {
+		prefix = DefaultSubjectPrefix
+	}
+	return &NATSPublisher{conn: conn, prefix: prefix}
+}
+
+func (p *NATSPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	data, err := proto.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	msg := nats.NewMsg(Subject(p.prefix, event))
+	msg.Data = data
+	msg.Header.Set(nats.MsgIdHdr, event.EventId)
+	msg.Header.Set(SchemaVersionHeader, strconv.Itoa(int(event.SchemaVersion)))
+	if err := p.conn.PublishMsg(msg); err != nil {
+		return err
+	}
+
+	// Only report success once the server has the message, so the outbox
+	// does not mark it published too early.
+	if _, ok := ctx.Deadline(); ok {
+		return p.conn.FlushWithContext(ctx)
+	}
+	return p.conn.Flush()
+}
@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+func runServer(t *testing.T) *server.Server {
+	t.Helper()
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, NoLog: true, NoSigs: true})
+	require.NoError(t, err)
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatal("nats server did not start")
+	}
+	t.Cleanup(ns.Shutdown)
+	return ns
+}
+
+func TestSubject(t *testing.T) {
+	assigned := &pb.Event{Payload: &pb.Event_OrderAssigned{OrderAssigned: &pb.OrderAssigned{OrderId: "order1"}}}
+	moved := &pb.Event{Payload: &pb.Event_DriverLocationUpdated{DriverLocationUpdated: &pb.DriverLocationUpdated{DeliveryPersonId: "dp1"}}}
+
+	assert.Equal(t, "fulfillment.events.v1.OrderAssigned", Subject(DefaultSubjectPrefix, assigned))
+	assert.Equal(t, "test.DriverLocationUpdated", Subject("test", moved))
+	assert.Equal(t, "test.Unknown", Subject("test", &pb.Event{}))
+}
+
+func TestNATSPublisher(t *testing.T) {
+	ns := runServer(t)
+
+	conn, err := nats.Connect(ns.ClientURL())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sub, err := conn.SubscribeSync(DefaultSubjectPrefix + ".>")
+	require.NoError(t, err)
+
+	event := &pb.Event{
+		EventId:       "e1",
+		SchemaVersion: 1,
+		OccurredAt:    1700000000,
+		Payload: &pb.Event_OrderStatusChanged{OrderStatusChanged: &pb.OrderStatusChanged{
+			OrderId:          "order1",
+			DeliveryPersonId: "dp1",
+			Status:           "IN_PROGRESS",
+		}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, NewNATSPublisher(conn, "").Publish(ctx, event))
+
+	msg, err := sub.NextMsg(5 * time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "fulfillment.events.v1.OrderStatusChanged", msg.Subject)
+	assert.Equal(t, "e1", msg.Header.Get(nats.MsgIdHdr))
+	assert.Equal(t, "1", msg.Header.Get(SchemaVersionHeader))
+
+	var got pb.Event
+	require.NoError(t, proto.Unmarshal(msg.Data, &got))
+	assert.True(t, proto.Equal(event, &got))
+}
+
+func TestMemoryPublisher(t *testing.T) {
+	publisher := NewMemoryPublisher()
+	event := &pb.Event{EventId: "e1", Payload: &pb.Event_OrderAssigned{OrderAssigned: &pb.OrderAssigned{OrderId: "order1"}}}
+
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	event.EventId = "changed"
+
+	events := publisher.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "e1", events[0].EventId)
+}
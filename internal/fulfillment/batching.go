@@ -0,0 +1,95 @@
+package fulfillment
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// findBatch looks for an assigned, not yet picked up standard order with a
+// pickup close to the new order's, whose delivery person still has room and would
+// not have to detour further than MaxDetourMeters to take both. It returns
+// the delivery person to offer the order to and the batch it would join, or
+// empty strings when no batch fits. Urgent orders are never batched.
+func (s *OrderService) findBatch(tx *gorm.DB, order *Order, asked *gorm.DB) (deliveryPersonID, batchID string, err error) {
+	if s.cfg.BatchWindow <= 0 || order.Pickup == nil || order.Dropoff == nil || priorityRanks[order.Priority] > 0 {
+		return "", "", nil
+	}
+
+	var anchors []Order
+	err = tx.Model(&Order{}).
+		Select("orders.*").
+		Joins("JOIN delivery_people ON delivery_people.delivery_person_id = orders.delivery_person_id").
+		Where("orders.status = ?", OrderStatusAssigned).
+		Where("orders.priority = ?", PriorityStandard).
+		Where("orders.created_at >= ?", s.now().Add(-s.cfg.BatchWindow).Unix()).
+		Where("ST_DWithin(orders.pickup, ?::geography, ?)", *order.Pickup, s.cfg.BatchPickupRadiusMeters).
+		Where("delivery_people.status = ?", DeliveryPersonAvailable).
+		Where("orders.delivery_person_id NOT IN (?)", asked).
+		Scopes(onShift, inZone(order), withSuitableVehicle(order), withSpareCapacity(order)).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ST_Distance(orders.pickup, ?::geography)", Vars: []interface{}{*order.Pickup}}}).
+		Find(&anchors).Error
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, anchor := range anchors {
+		if anchor.Pickup == nil || anchor.Dropoff == nil {
+			continue
+		}
+		if batchDetourMeters(&anchor, order) > s.cfg.MaxDetourMeters {
+			continue
+		}
+		batchID = anchor.BatchID
+		if batchID == "" {
+			batchID = anchor.OrderID
+		}
+		return anchor.DeliveryPersonID, batchID, nil
+	}
+	return "", "", nil
+}
+
+// leaveBatch fixes up the rest of order's batch once order has been taken
+// out of it: a single remaining order is no longer batched, and if order was
+// the first in the batch the others take the ID of the next one.
+func leaveBatch(tx *gorm.DB, order *Order) error {
+	if order.BatchID == "" {
+		return nil
+	}
+
+	var rest []string
+	if err := tx.Model(&Order{}).
+		Where("batch_id = ? AND order_id <> ?", order.BatchID, order.OrderID).
+		Order("created_at").
+		Pluck("order_id", &rest).Error; err != nil {
+		return err
+	}
+
+	var batchID string
+	switch {
+	case len(rest) == 0:
+		return nil
+	case len(rest) == 1:
+		batchID = ""
+	case order.BatchID == order.OrderID:
+		batchID = rest[0]
+	default:
+		return nil
+	}
+	return tx.Model(&Order{}).Where("order_id IN ?", rest).Update("batch_id", batchID).Error
+}
+
+// batchDetourMeters is the extra distance needed to serve next alongside
+// anchor: collect both pickups, then drop off in whichever order is shorter,
+// compared with serving anchor on its own.
+func batchDetourMeters(anchor, next *Order) float64 {
+	alone := distanceMeters(*anchor.Pickup, *anchor.Dropoff)
+
+	toNextPickup := distanceMeters(*anchor.Pickup, *next.Pickup)
+	between := distanceMeters(*anchor.Dropoff, *next.Dropoff)
+	anchorFirst := distanceMeters(*next.Pickup, *anchor.Dropoff) + between
+	nextFirst := distanceMeters(*next.Pickup, *next.Dropoff) + between
+
+	return toNextPickup + math.Min(anchorFirst, nextFirst) - alone
+}
@@ -0,0 +1,139 @@
+package fulfillment
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// ewkb encodes p the way PostGIS returns a geography point.
+func ewkb(p Point) string {
+	raw := make([]byte, 25)
+	raw[0] = 1
+	binary.LittleEndian.PutUint32(raw[1:5], wkbPoint|ewkbSRID)
+	binary.LittleEndian.PutUint32(raw[5:9], sridWGS84)
+	binary.LittleEndian.PutUint64(raw[9:17], math.Float64bits(p.Lng))
+	binary.LittleEndian.PutUint64(raw[17:25], math.Float64bits(p.Lat))
+	return hex.EncodeToString(raw)
+}
+
+var (
+	restaurant  = Point{Lat: 40.7484, Lng: -73.9857}
+	nearbyHouse = Point{Lat: 40.7527, Lng: -73.9772}
+	nextDoor    = Point{Lat: 40.7530, Lng: -73.9768}
+	brooklyn    = Point{Lat: 40.6782, Lng: -73.9442}
+)
+
+func TestBatchDetourMeters(t *testing.T) {
+	anchor := &Order{Pickup: &restaurant, Dropoff: &nearbyHouse}
+
+	close := batchDetourMeters(anchor, &Order{Pickup: &restaurant, Dropoff: &nextDoor})
+	far := batchDetourMeters(anchor, &Order{Pickup: &restaurant, Dropoff: &brooklyn})
+
+	assert.Less(t, close, 100.0)
+	assert.Greater(t, far, 5000.0)
+}
+
+func TestAssignOrderBatchesSamePickup(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Offer To Driver Already Collecting From Same Pickup", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectZoneLookup(mock, "")
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT orders\.\* FROM "orders" JOIN delivery_people .* WHERE orders\.status = \$1 AND orders\.priority = \$2 AND orders\.created_at >= \$3 AND ST_DWithin\(orders\.pickup, \$4::geography, \$5\) AND delivery_people\.status = \$6`).
+			WithArgs("ASSIGNED", "STANDARD", now.Add(-10*time.Minute).Unix(), sqlmock.AnyArg(), 200.0, "AVAILABLE", "order2", "PENDING",
+				"BIKE", "SCOOTER", "CAR", "VAN",
+				"ASSIGNED", "IN_PROGRESS", "RETURNING", 0.0, "ASSIGNED", "IN_PROGRESS", "RETURNING", 0.0, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "pickup", "dropoff", "batch_id"}).
+				AddRow("order1", "dp1", "ASSIGNED", ewkb(restaurant), ewkb(nearbyHouse), ""))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order2", "dp1", "PENDING", "order1", "", now.Unix()+30)...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOfferEstimate(mock, "dp1", restaurant)
+		mock.ExpectCommit()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+			OrderId: "order2",
+			Pickup:  &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+			Dropoff: &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dp1", resp.DeliveryPersonId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Detour Too Long Falls Back To Nearest Driver", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectZoneLookup(mock, "")
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT orders\.\* FROM "orders" JOIN delivery_people`).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "pickup", "dropoff", "batch_id"}).
+				AddRow("order1", "dp1", "ASSIGNED", ewkb(restaurant), ewkb(nearbyHouse), ""))
+		expectCandidateQuery(mock, "order3", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order3", "dp2", "PENDING", "", "", now.Unix()+30)...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOfferEstimate(mock, "dp2", nearbyHouse)
+		mock.ExpectCommit()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+			OrderId: "order3",
+			Pickup:  &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+			Dropoff: &pb.Location{Lat: brooklyn.Lat, Lng: brooklyn.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dp2", resp.DeliveryPersonId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAcceptBatchedOffer(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+		WithArgs("offer1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"offer_id", "order_id", "delivery_person_id", "status", "batch_id", "expires_at"}).
+			AddRow("offer1", "order2", "dp1", "PENDING", "order1", now.Unix()+10))
+	expectOfferedOrderLock(mock, "order2", true)
+	expectDriverFits(mock, "dp1", true)
+	mock.ExpectExec(`UPDATE "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "delivery_person_id"=\$1,"status"=\$2,"status_changed_at"=\$3`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.assigned", "order2")
+	expectPinIssued(mock, "order2")
+	mock.ExpectExec(`UPDATE "orders" SET "batch_id"=\$1,"updated_at"=\$2 WHERE order_id IN \(\$3,\$4\)`).
+		WithArgs("order1", sqlmock.AnyArg(), "order1", "order2").
+		WillReturnResult(sqlmock.NewResult(2, 2))
+	expectDriverStatusRefresh(mock, "dp1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+	mock.ExpectCommit()
+
+	resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer1", DeliveryPersonId: "dp1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "ACCEPTED", resp.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,44 @@
+package fulfillment
+
+import "gorm.io/gorm"
+
+// withSpareCapacity limits a delivery_people query to drivers whose remaining
+// weight and volume allowance can take the given order on top of their
+// current active orders.
+func withSpareCapacity(order *Order) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("max_weight_kg = 0 OR max_weight_kg - (SELECT COALESCE(SUM(weight_kg), 0) FROM orders WHERE orders.delivery_person_id = delivery_people.delivery_person_id AND orders.status IN ?) >= ?",
+				activeOrderStatuses, order.WeightKg).
+			Where("max_volume_liters = 0 OR max_volume_liters - (SELECT COALESCE(SUM(volume_liters), 0) FROM orders WHERE orders.delivery_person_id = delivery_people.delivery_person_id AND orders.status IN ?) >= ?",
+				activeOrderStatuses, order.VolumeLiters)
+	}
+}
+
+// withSpareCapacityInPlaceOf is withSpareCapacity for a query over orders
+// joined to their delivery people: it counts each driver's load without the
+// joined order, which is about to be taken off them to make room for this
+// one.
+func withSpareCapacityInPlaceOf(order *Order) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Where("delivery_people.max_weight_kg = 0 OR delivery_people.max_weight_kg - (SELECT COALESCE(SUM(held.weight_kg), 0) FROM orders AS held WHERE held.delivery_person_id = delivery_people.delivery_person_id AND held.status IN ?) + orders.weight_kg >= ?",
+				activeOrderStatuses, order.WeightKg).
+			Where("delivery_people.max_volume_liters = 0 OR delivery_people.max_volume_liters - (SELECT COALESCE(SUM(held.volume_liters), 0) FROM orders AS held WHERE held.delivery_person_id = delivery_people.delivery_person_id AND held.status IN ?) + orders.volume_liters >= ?",
+				activeOrderStatuses, order.VolumeLiters)
+	}
+}
+
+// refreshDriverStatus recomputes a delivery person's status from their
+// current load: BUSY once the number of active orders reaches capacity,
+// AVAILABLE otherwise.
+func refreshDriverStatus(tx *gorm.DB, deliveryPersonID string) error {
+	load := tx.Model(&Order{}).
+		Select("COUNT(*)").
+		Where("delivery_person_id = ? AND status IN ?", deliveryPersonID, activeOrderStatuses)
+
+	return tx.Model(&DeliveryPerson{DeliveryPersonID: deliveryPersonID}).
+		Where("status IN ?", []string{DeliveryPersonAvailable, DeliveryPersonBusy}).
+		Update("status", gorm.Expr("CASE WHEN (?) >= capacity THEN ? ELSE ? END",
+			load, DeliveryPersonBusy, DeliveryPersonAvailable)).Error
+}
@@ -0,0 +1,23 @@
+package fulfillment
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestWithSpareCapacity(t *testing.T) {
+	db, _, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	var candidates []DeliveryPerson
+	stmt := db.Session(&gorm.Session{DryRun: true}).
+		Model(&DeliveryPerson{}).
+		Scopes(withSpareCapacity(&Order{WeightKg: 3, VolumeLiters: 12})).
+		Find(&candidates).Statement
+
+	assert.Contains(t, stmt.SQL.String(), "max_weight_kg = 0 OR max_weight_kg - (SELECT COALESCE(SUM(weight_kg), 0) FROM orders")
+	assert.Contains(t, stmt.SQL.String(), "max_volume_liters = 0 OR max_volume_liters - (SELECT COALESCE(SUM(volume_liters), 0) FROM orders")
+	assert.Equal(t, []interface{}{"ASSIGNED", "IN_PROGRESS", "RETURNING", 3.0, "ASSIGNED", "IN_PROGRESS", "RETURNING", 12.0}, stmt.Vars)
+}
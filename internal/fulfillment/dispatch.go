@@ -0,0 +1,56 @@
+package fulfillment
+
+import (
+	"errors"
+	"math"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// dispatchRings lists the search radii tried in turn when looking for the
+// nearest delivery person, ending with MaxDispatchRadiusMeters. A final
+// radius of zero means the search is unbounded.
+func (s *OrderService) dispatchRings() []float64 {
+	limit := math.Max(s.cfg.MaxDispatchRadiusMeters, 0)
+	var rings []float64
+	for _, radius := range s.cfg.DispatchRingsMeters {
+		if radius <= 0 || (limit > 0 && radius >= limit) {
+			continue
+		}
+		if len(rings) > 0 && radius <= rings[len(rings)-1] {
+			continue
+		}
+		rings = append(rings, radius)
+	}
+	return append(rings, limit)
+}
+
+// nearestCandidate finds the closest available delivery person to pickup,
+// searching in expanding rings so the common case only touches the part of
+// the location index near the pickup. It returns an empty string when no one
+// is within MaxDispatchRadiusMeters.
+func (s *OrderService) nearestCandidate(tx *gorm.DB, order *Order, pickup Point, asked *gorm.DB) (string, error) {
+	for _, radius := range s.dispatchRings() {
+		query := tx.Model(&DeliveryPerson{}).
+			Where("status = ?", DeliveryPersonAvailable).
+			Where("delivery_person_id NOT IN (?)", asked)
+		if radius > 0 {
+			query = query.Where("ST_DWithin(location, ?::geography, ?)", pickup, radius)
+		}
+
+		var candidate DeliveryPerson
+		err := query.
+			Scopes(onShift, inZone(order), withSuitableVehicle(order), withSpareCapacity(order)).
+			Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ST_Distance(location, ?::geography)", Vars: []interface{}{pickup}}}).
+			Take(&candidate).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return candidate.DeliveryPersonID, nil
+	}
+	return "", nil
+}
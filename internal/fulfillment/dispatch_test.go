@@ -0,0 +1,66 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDispatchRings(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.Equal(t, []float64{1000, 3000, 5000}, NewService(nil, WithConfig(cfg)).dispatchRings())
+
+	cfg.MaxDispatchRadiusMeters = 2000
+	assert.Equal(t, []float64{1000, 2000}, NewService(nil, WithConfig(cfg)).dispatchRings())
+
+	cfg.DispatchRingsMeters = []float64{3000, 500, 0}
+	cfg.MaxDispatchRadiusMeters = 0
+	assert.Equal(t, []float64{3000, 0}, NewService(nil, WithConfig(cfg)).dispatchRings())
+}
+
+func TestAssignOrderExpandsSearchRings(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	cfg := DefaultConfig()
+	cfg.BatchWindow = 0
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Widens Search Until Someone Is Found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id"}))
+		expectCandidateQuery(mock, "order1", 3000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp1", "AVAILABLE"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "dp1", resp.DeliveryPersonId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Nobody Within Max Radius", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectNoCandidates(mock, "order2")
+		mock.ExpectRollback()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order2"})
+
+		assert.Equal(t, "FAILED", resp.Status)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,153 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func (s *OrderService) UpdateDeliveryPersonLocation(ctx context.Context, req *pb.UpdateDeliveryPersonLocationRequest) (*pb.UpdateDeliveryPersonLocationResponse, error) {
+	if req.Location == nil {
+		return nil, status.Error(codes.InvalidArgument, "location is required")
+	}
+
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var driver DeliveryPerson
+		err := tx.First(&driver, "delivery_person_id = ?", req.DeliveryPersonId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "delivery person not found")
+		}
+		if err != nil {
+			return err
+		}
+
+		driver.Location = pointFromProto(req.Location)
+		zoneID, err := zoneAt(tx, *driver.Location)
+		if err != nil {
+			return err
+		}
+		driver.ZoneID = zoneID
+		if err := tx.Model(&driver).Updates(map[string]interface{}{
+			"location": *driver.Location,
+			"zone_id":  driver.ZoneID,
+		}).Error; err != nil {
+			return err
+		}
+		if err := s.markSeen(tx, driver.DeliveryPersonID); err != nil {
+			return err
+		}
+		if err := s.recordEvent(tx, driver.DeliveryPersonID, &pb.Event{
+			Payload: &pb.Event_DriverLocationUpdated{DriverLocationUpdated: &pb.DriverLocationUpdated{
+				DeliveryPersonId: driver.DeliveryPersonID,
+				Lat:              driver.Location.Lat,
+				Lng:              driver.Location.Lng,
+				ZoneId:           driver.ZoneID,
+			}},
+		}); err != nil {
+			return err
+		}
+
+		var stops []RouteStop
+		if err := tx.Where("delivery_person_id = ?", driver.DeliveryPersonID).
+			Order("sequence").
+			Find(&stops).Error; err != nil {
+			return err
+		}
+		return s.updateETAs(tx, &driver, stops)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.UpdateDeliveryPersonLocationResponse{Status: "UPDATED"}, nil
+}
+
+// travelTime estimates how long a delivery person takes to cover a straight
+// line distance, allowing for the road network, their vehicle's speed and
+// congestion in the zone.
+func (s *OrderService) travelTime(meters float64, vehicleType, zoneID string) time.Duration {
+	speed := s.cfg.SpeedProfilesKmh[vehicleType]
+	if speed <= 0 {
+		speed = s.cfg.DefaultSpeedKmh
+	}
+	congestion := s.cfg.CongestionFactors[zoneID]
+	if congestion <= 0 {
+		congestion = 1
+	}
+
+	hours := meters * s.cfg.RoadDistanceFactor / 1000 / speed * congestion
+	return time.Duration(hours * float64(time.Hour))
+}
+
+// estimateOffer records on the order when the offered delivery person would
+// reach its pickup and drop-off going straight there from where they are now.
+func (s *OrderService) estimateOffer(tx *gorm.DB, order *Order, deliveryPersonID string) error {
+	if order.Pickup == nil || order.Dropoff == nil {
+		return nil
+	}
+
+	var driver DeliveryPerson
+	if err := tx.First(&driver, "delivery_person_id = ?", deliveryPersonID).Error; err != nil {
+		return err
+	}
+	start := *order.Pickup
+	if driver.Location != nil {
+		start = *driver.Location
+	}
+
+	toPickup := s.travelTime(distanceMeters(start, *order.Pickup), driver.VehicleType, order.ZoneID)
+	toDropoff := s.travelTime(distanceMeters(*order.Pickup, *order.Dropoff), driver.VehicleType, order.ZoneID)
+	order.PickupETA = s.now().Add(toPickup).Unix()
+	order.DeliveryETA = s.now().Add(toPickup + s.cfg.StopDwell + toDropoff).Unix()
+
+	return tx.Model(order).Updates(map[string]interface{}{
+		"pickup_eta":   order.PickupETA,
+		"delivery_eta": order.DeliveryETA,
+	}).Error
+}
+
+// updateETAs walks a delivery person's planned stops from their current
+// position and stores the expected arrival at each order's pickup and
+// drop-off. For a returning order the delivery ETA is its arrival back at
+// the pickup.
+func (s *OrderService) updateETAs(tx *gorm.DB, driver *DeliveryPerson, stops []RouteStop) error {
+	prev := driver.Location
+	var orderIDs []string
+	etas := make(map[string]map[string]interface{})
+	elapsed := time.Duration(0)
+	for _, stop := range stops {
+		if stop.Location == nil {
+			continue
+		}
+		if prev == nil {
+			prev = stop.Location
+		}
+		elapsed += s.travelTime(distanceMeters(*prev, *stop.Location), driver.VehicleType, driver.ZoneID)
+		arrival := s.now().Add(elapsed).Unix()
+		elapsed += s.cfg.StopDwell
+		prev = stop.Location
+
+		if _, ok := etas[stop.OrderID]; !ok {
+			orderIDs = append(orderIDs, stop.OrderID)
+			etas[stop.OrderID] = make(map[string]interface{})
+		}
+		if stop.Kind == StopPickup {
+			etas[stop.OrderID]["pickup_eta"] = arrival
+		} else {
+			etas[stop.OrderID]["delivery_eta"] = arrival
+		}
+	}
+
+	for _, orderID := range orderIDs {
+		if err := tx.Model(&Order{OrderID: orderID}).Updates(etas[orderID]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expectOfferEstimate expects the ETA for an offer to be computed from the
+// offered delivery person's position and stored on the order.
+func expectOfferEstimate(mock sqlmock.Sqlmock, deliveryPersonID string, location Point) {
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1`).
+		WithArgs(deliveryPersonID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "location"}).
+			AddRow(deliveryPersonID, "AVAILABLE", ewkb(location)))
+	mock.ExpectExec(`UPDATE "orders" SET "delivery_eta"=\$1,"pickup_eta"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestTravelTime(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.RoadDistanceFactor = 1
+	cfg.SpeedProfilesKmh = map[string]float64{VehicleCar: 30}
+	cfg.CongestionFactors = map[string]float64{"midtown": 2}
+	service := NewService(nil, WithConfig(cfg))
+
+	assert.Equal(t, 30*time.Minute, service.travelTime(10000, "", ""))
+	assert.Equal(t, 20*time.Minute, service.travelTime(10000, VehicleCar, ""))
+	assert.Equal(t, 40*time.Minute, service.travelTime(10000, VehicleCar, "midtown"))
+}
+
+func TestAssignOrderEstimatesArrival(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	cfg := DefaultConfig()
+	cfg.BatchWindow = 0
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	expectZoneLookup(mock, "")
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp1", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOfferEstimate(mock, "dp1", nearbyHouse)
+	mock.ExpectCommit()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+		OrderId: "order1",
+		Pickup:  &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		Dropoff: &pb.Location{Lat: brooklyn.Lat, Lng: brooklyn.Lng},
+	})
+
+	assert.NoError(t, err)
+	assert.Greater(t, resp.PickupEta, now.Unix())
+	assert.Greater(t, resp.DeliveryEta, resp.PickupEta+int64(cfg.StopDwell.Seconds()))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateDeliveryPersonLocation(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	cfg := DefaultConfig()
+	cfg.CongestionFactors = map[string]float64{"midtown": 1.5}
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Recomputes ETAs Along Planned Route", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1`).
+			WithArgs("dp1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "location"}).AddRow("dp1", "BUSY", ewkb(brooklyn)))
+		expectZoneLookup(mock, "midtown")
+		mock.ExpectExec(`UPDATE "delivery_people" SET "location"=\$1,"zone_id"=\$2 WHERE "delivery_person_id" = \$3`).
+			WithArgs("SRID=4326;POINT(-73.9772 40.7527)", "midtown", "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectMarkSeen(mock, "dp1", now.Unix(), false)
+		expectEvent(mock, "driver.location_updated", "dp1")
+		mock.ExpectQuery(`SELECT \* FROM "route_stops" WHERE delivery_person_id = \$1 ORDER BY sequence`).
+			WithArgs("dp1").
+			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "sequence", "order_id", "kind", "location"}).
+				AddRow("dp1", 1, "order1", "DROPOFF", ewkb(nextDoor)))
+		mock.ExpectExec(`UPDATE "orders" SET "delivery_eta"=\$1,"updated_at"=\$2 WHERE "order_id" = \$3`).
+			WithArgs(now.Add(service.travelTime(distanceMeters(nearbyHouse, nextDoor), "", "midtown")).Unix(), sqlmock.AnyArg(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.UpdateDeliveryPersonLocation(context.Background(), &pb.UpdateDeliveryPersonLocationRequest{
+			DeliveryPersonId: "dp1",
+			Location:         &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "UPDATED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Missing Location", func(t *testing.T) {
+		resp, err := service.UpdateDeliveryPersonLocation(context.Background(), &pb.UpdateDeliveryPersonLocationRequest{DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Unknown Delivery Person", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1`).
+			WithArgs("dp9", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id"}))
+		mock.ExpectRollback()
+
+		resp, err := service.UpdateDeliveryPersonLocation(context.Background(), &pb.UpdateDeliveryPersonLocationRequest{
+			DeliveryPersonId: "dp9",
+			Location:         &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
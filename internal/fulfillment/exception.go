@@ -0,0 +1,167 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"slices"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	ExceptionCustomerUnreachable = "CUSTOMER_UNREACHABLE"
+	ExceptionWrongAddress        = "WRONG_ADDRESS"
+	ExceptionRefused             = "REFUSED"
+	ExceptionDamaged             = "DAMAGED"
+
+	// ExceptionRetry means the delivery person should try the drop-off
+	// again after the order's RetryAfter, and no further exception is
+	// accepted before then; ExceptionReturn that they should take the order
+	// back to its pickup.
+	ExceptionRetry  = "RETRY"
+	ExceptionReturn = "RETURN"
+)
+
+var exceptionReasons = []string{
+	ExceptionCustomerUnreachable,
+	ExceptionWrongAddress,
+	ExceptionRefused,
+	ExceptionDamaged,
+}
+
+// ReportDeliveryException records a failed delivery attempt. Reasons listed
+// in RetryableExceptions are retried after DeliveryRetryWait until
+// MaxDeliveryAttempts is reached; anything else sends the order back to its
+// pickup.
+func (s *OrderService) ReportDeliveryException(ctx context.Context, req *pb.ReportDeliveryExceptionRequest) (*pb.ReportDeliveryExceptionResponse, error) {
+	if !slices.Contains(exceptionReasons, req.Reason) {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown exception reason %q", req.Reason)
+	}
+
+	var order *Order
+	var exception DeliveryException
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var err error
+		order, err = s.lockDriverOrder(tx, req.OrderId, req.DeliveryPersonId, OrderStatusInProgress)
+		if err != nil {
+			return err
+		}
+		// A retry only counts once the wait is over, so that attempts are
+		// not used up by reporting the same failed drop-off again.
+		if now := s.now().Unix(); now < order.RetryAfter {
+			return status.Errorf(codes.FailedPrecondition, "next delivery attempt is not due for %ds", order.RetryAfter-now)
+		}
+
+		order.DeliveryAttempts++
+		exception = DeliveryException{
+			ExceptionID:      newID(),
+			OrderID:          order.OrderID,
+			DeliveryPersonID: order.DeliveryPersonID,
+			Reason:           req.Reason,
+			Note:             req.Note,
+			Location:         pointFromProto(req.Location),
+			Attempt:          order.DeliveryAttempts,
+			Action:           ExceptionReturn,
+			ReportedAt:       s.now().Unix(),
+		}
+		if slices.Contains(s.cfg.RetryableExceptions, req.Reason) && order.DeliveryAttempts < s.cfg.MaxDeliveryAttempts {
+			exception.Action = ExceptionRetry
+		}
+		if err := tx.Create(&exception).Error; err != nil {
+			return err
+		}
+
+		if exception.Action == ExceptionRetry {
+			order.RetryAfter = s.now().Add(s.cfg.DeliveryRetryWait).Unix()
+			return tx.Model(order).Updates(map[string]interface{}{
+				"delivery_attempts": order.DeliveryAttempts,
+				"retry_after":       order.RetryAfter,
+			}).Error
+		}
+
+		previous := *order
+		order.Status = OrderStatusReturning
+		order.RetryAfter = 0
+		if err := tx.Model(order).Updates(map[string]interface{}{
+			"delivery_attempts": order.DeliveryAttempts,
+			"retry_after":       order.RetryAfter,
+			"status":            order.Status,
+			"status_changed_at": s.now().Unix(),
+		}).Error; err != nil {
+			return err
+		}
+		s.observeStatusChange(tx, &previous, order.Status)
+		if err := s.recordOrderEvent(tx, EventOrderStatusChanged, order.OrderID, order.DeliveryPersonID, order.Status); err != nil {
+			return err
+		}
+		return s.planRoute(tx, order.DeliveryPersonID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.ReportDeliveryExceptionResponse{
+		Status:     order.Status,
+		Action:     exception.Action,
+		Attempt:    int32(exception.Attempt),
+		RetryAfter: order.RetryAfter,
+	}, nil
+}
+
+// CompleteReturn records that a returning order is back at its pickup and
+// frees the delivery person.
+func (s *OrderService) CompleteReturn(ctx context.Context, req *pb.CompleteReturnRequest) (*pb.CompleteReturnResponse, error) {
+	if req.Location == nil {
+		return nil, status.Error(codes.InvalidArgument, "location is required")
+	}
+
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		order, err := s.lockDriverOrder(tx, req.OrderId, req.DeliveryPersonId, OrderStatusReturning)
+		if err != nil {
+			return err
+		}
+		if order.Pickup != nil && s.cfg.MaxHandoffDistanceMeters > 0 {
+			if d := distanceMeters(*pointFromProto(req.Location), *order.Pickup); d > s.cfg.MaxHandoffDistanceMeters {
+				return status.Errorf(codes.FailedPrecondition, "delivery person is %.0f m from the pickup", d)
+			}
+		}
+
+		if err := s.setOrderStatus(tx, order, OrderStatusReturned); err != nil {
+			return err
+		}
+		if err := refreshDriverStatus(tx, order.DeliveryPersonID); err != nil {
+			return err
+		}
+		return s.planRoute(tx, order.DeliveryPersonID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CompleteReturnResponse{Status: OrderStatusReturned}, nil
+}
+
+// lockDriverOrder loads an order for update, checking it belongs to the
+// delivery person and is in the expected state.
+func (s *OrderService) lockDriverOrder(tx *gorm.DB, orderID, deliveryPersonID, want string) (*Order, error) {
+	var order Order
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, "order_id = ?", orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "order not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if order.DeliveryPersonID != deliveryPersonID {
+		return nil, status.Error(codes.PermissionDenied, "order is assigned to another delivery person")
+	}
+	if order.Status != want {
+		return nil, status.Errorf(codes.FailedPrecondition, "order is %s, not %s", order.Status, want)
+	}
+	return &order, nil
+}
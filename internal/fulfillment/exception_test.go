@@ -0,0 +1,179 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestReportDeliveryException(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	expectLock := func(orderID string, attempts int, retryAfter int64) {
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 ORDER BY .* FOR UPDATE`).
+			WithArgs(orderID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "delivery_attempts", "retry_after"}).
+				AddRow(orderID, "dp1", "IN_PROGRESS", attempts, retryAfter))
+	}
+
+	t.Run("Success - Customer Unreachable Is Retried", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order1", 0, 0)
+		mock.ExpectExec(`INSERT INTO "delivery_exceptions"`).
+			WithArgs(sqlmock.AnyArg(), "order1", "dp1", "CUSTOMER_UNREACHABLE", "no answer at door", sqlmock.AnyArg(), 1, "RETRY", now.Unix()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "orders" SET "delivery_attempts"=\$1,"retry_after"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs(1, now.Add(10*time.Minute).Unix(), sqlmock.AnyArg(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.ReportDeliveryException(context.Background(), &pb.ReportDeliveryExceptionRequest{
+			OrderId:          "order1",
+			DeliveryPersonId: "dp1",
+			Reason:           "CUSTOMER_UNREACHABLE",
+			Note:             "no answer at door",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "RETRY", resp.Action)
+		assert.Equal(t, "IN_PROGRESS", resp.Status)
+		assert.Equal(t, now.Add(10*time.Minute).Unix(), resp.RetryAfter)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Last Attempt Returns To Origin", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order2", 1, now.Unix())
+		mock.ExpectExec(`INSERT INTO "delivery_exceptions"`).
+			WithArgs(sqlmock.AnyArg(), "order2", "dp1", "CUSTOMER_UNREACHABLE", "", sqlmock.AnyArg(), 2, "RETURN", now.Unix()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "orders" SET "delivery_attempts"=\$1,"retry_after"=\$2,"status"=\$3,"status_changed_at"=\$4,"updated_at"=\$5 WHERE "order_id" = \$6`).
+			WithArgs(2, 0, "RETURNING", sqlmock.AnyArg(), sqlmock.AnyArg(), "order2").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order2")
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+		mock.ExpectCommit()
+
+		resp, err := service.ReportDeliveryException(context.Background(), &pb.ReportDeliveryExceptionRequest{
+			OrderId:          "order2",
+			DeliveryPersonId: "dp1",
+			Reason:           "CUSTOMER_UNREACHABLE",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "RETURN", resp.Action)
+		assert.Equal(t, "RETURNING", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Refused Is Returned Straight Away", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order3", 0, 0)
+		mock.ExpectExec(`INSERT INTO "delivery_exceptions"`).
+			WithArgs(sqlmock.AnyArg(), "order3", "dp1", "REFUSED", "", sqlmock.AnyArg(), 1, "RETURN", now.Unix()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "orders" SET "delivery_attempts"=\$1,"retry_after"=\$2,"status"=\$3,"status_changed_at"=\$4`).
+			WithArgs(1, 0, "RETURNING", sqlmock.AnyArg(), sqlmock.AnyArg(), "order3").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order3")
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+		mock.ExpectCommit()
+
+		resp, err := service.ReportDeliveryException(context.Background(), &pb.ReportDeliveryExceptionRequest{
+			OrderId:          "order3",
+			DeliveryPersonId: "dp1",
+			Reason:           "REFUSED",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "RETURN", resp.Action)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Retry Not Due Yet", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order4", 1, now.Add(5*time.Minute).Unix())
+		mock.ExpectRollback()
+
+		resp, err := service.ReportDeliveryException(context.Background(), &pb.ReportDeliveryExceptionRequest{
+			OrderId:          "order4",
+			DeliveryPersonId: "dp1",
+			Reason:           "CUSTOMER_UNREACHABLE",
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "300s")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Reason", func(t *testing.T) {
+		resp, err := service.ReportDeliveryException(context.Background(), &pb.ReportDeliveryExceptionRequest{OrderId: "order1", Reason: "DOG"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestCompleteReturn(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	expectLock := func(orderID string) {
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 ORDER BY .* FOR UPDATE`).
+			WithArgs(orderID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "pickup"}).
+				AddRow(orderID, "dp1", "RETURNING", ewkb(restaurant)))
+	}
+
+	t.Run("Success - Back At Pickup", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order1")
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("RETURNED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order1")
+		expectDriverStatusRefresh(mock, "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+		mock.ExpectCommit()
+
+		resp, err := service.CompleteReturn(context.Background(), &pb.CompleteReturnRequest{
+			OrderId:          "order1",
+			DeliveryPersonId: "dp1",
+			Location:         &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "RETURNED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Not At Pickup", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLock("order2")
+		mock.ExpectRollback()
+
+		resp, err := service.CompleteReturn(context.Background(), &pb.CompleteReturnRequest{
+			OrderId:          "order2",
+			DeliveryPersonId: "dp1",
+			Location:         &pb.Location{Lat: brooklyn.Lat, Lng: brooklyn.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
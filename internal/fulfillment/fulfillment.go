@@ -1,21 +1,278 @@
 package fulfillment
 
+const (
+	OrderStatusScheduled  = "SCHEDULED"
+	OrderStatusOffered    = "OFFERED"
+	OrderStatusAssigned   = "ASSIGNED"
+	OrderStatusInProgress = "IN_PROGRESS"
+	OrderStatusDelivered  = "DELIVERED"
+	OrderStatusUnassigned = "UNASSIGNED"
+	OrderStatusReturning  = "RETURNING"
+	OrderStatusReturned   = "RETURNED"
+
+	DeliveryPersonAvailable = "AVAILABLE"
+	DeliveryPersonBusy      = "BUSY"
+	DeliveryPersonOffline   = "OFFLINE"
+	DeliveryPersonStale     = "STALE"
+
+	OfferPending  = "PENDING"
+	OfferAccepted = "ACCEPTED"
+	OfferDeclined = "DECLINED"
+	OfferExpired  = "EXPIRED"
+)
+
+// activeOrderStatuses are the order states that count towards a delivery
+// person's load.
+var activeOrderStatuses = []string{OrderStatusAssigned, OrderStatusInProgress, OrderStatusReturning}
+
+// Order is a package to be picked up and delivered.
 type Order struct {
-	OrderID          string `gorm:"primaryKey"`
+	OrderID string `gorm:"primaryKey"`
+	// DeliveryPersonID is NULL in the database until the order is accepted.
 	DeliveryPersonID string
 	Status           string
-	CreatedAt        int64
-	UpdatedAt        int64
+	WeightKg         float64
+	VolumeLiters     float64
+	// PackageSize decides which vehicle types can carry the order.
+	PackageSize string
+	Pickup      *Point `gorm:"column:pickup"`
+	Dropoff     *Point `gorm:"column:dropoff"`
+	// BatchID is shared by orders batched onto the same delivery person; it
+	// is the ID of the first order in the batch.
+	BatchID string
+	// ZoneID is the zone covering the pickup, empty outside every zone.
+	ZoneID      string
+	PickupETA   int64 `gorm:"column:pickup_eta"`
+	DeliveryETA int64 `gorm:"column:delivery_eta"`
+	// NeedsAttention is set when ops should look at the order, with
+	// AttentionReason saying why.
+	NeedsAttention  bool
+	AttentionReason string
+	// DeliveryAttempts counts failed drop-offs; RetryAfter is when the next
+	// one is due.
+	DeliveryAttempts int
+	RetryAfter       int64
+	// An order with a promised window stays SCHEDULED until DispatchAt.
+	// WindowOutcome records whether the window was met.
+	WindowStart   int64
+	WindowEnd     int64
+	DispatchAt    int64
+	WindowOutcome string
+	// Priority decides which orders are dispatched first and which may be
+	// preempted.
+	Priority string
+	// SLAStatus is AT_RISK or BREACHED once the order is in danger of
+	// missing, or has missed, SLADueAt.
+	SLAStatus string `gorm:"column:sla_status"`
+	SLADueAt  int64  `gorm:"column:sla_due_at"`
+	// StatusChangedAt is when the order entered its current status.
+	StatusChangedAt int64
+	CreatedAt       int64
+	UpdatedAt       int64
 }
 
+// DeliveryPerson is a courier who can be offered orders.
 type DeliveryPerson struct {
 	DeliveryPersonID string `gorm:"column:delivery_person_id;primaryKey"`
 	Name             string `gorm:"column:name"`
-	Status           string `gorm:"column:status"`
-	Location         *Point `gorm:"column:location"`
+	// Status is OFFLINE outside a shift, STALE or OFFLINE once they have
+	// gone quiet for too long, and otherwise derived from the number of
+	// active orders against Capacity.
+	Status   string `gorm:"column:status"`
+	Location *Point `gorm:"column:location"`
+	Capacity int    `gorm:"column:capacity"`
+	// MaxWeightKg and MaxVolumeLiters limit the combined load; zero means
+	// unlimited.
+	MaxWeightKg     float64 `gorm:"column:max_weight_kg"`
+	MaxVolumeLiters float64 `gorm:"column:max_volume_liters"`
+	VehicleType     string  `gorm:"column:vehicle_type"`
+	// ZoneID follows the last reported location.
+	ZoneID     string `gorm:"column:zone_id"`
+	LastSeenAt int64  `gorm:"column:last_seen_at"`
 }
 
 type Point struct {
 	Lat float64
 	Lng float64
 }
+
+// Offer is a time-boxed proposal for a delivery person to take an order.
+// Every offer is kept once it is answered so acceptance rates can be derived.
+type Offer struct {
+	OfferID          string `gorm:"primaryKey"`
+	OrderID          string
+	DeliveryPersonID string
+	Status           string
+	BatchID          string
+	DeclineReason    string
+	ExpiresAt        int64
+	RespondedAt      int64
+	CreatedAt        int64
+}
+
+// RouteStop is one stop in a delivery person's planned route. LegMeters is
+// the distance from the previous stop, or from the delivery person's
+// position for the first one.
+type RouteStop struct {
+	DeliveryPersonID string `gorm:"primaryKey"`
+	Sequence         int    `gorm:"primaryKey"`
+	OrderID          string
+	Kind             string
+	Location         *Point `gorm:"column:location"`
+	LegMeters        float64
+}
+
+// Zone is a delivery area. Orders are only dispatched to delivery people in
+// the zone covering their pickup.
+type Zone struct {
+	ZoneID    string `gorm:"primaryKey"`
+	Name      string
+	Boundary  GeoJSON `gorm:"column:boundary"`
+	CreatedAt int64
+	UpdatedAt int64
+}
+
+// Shift is one on-duty session of a delivery person. EndedAt is zero while
+// the shift is in progress.
+type Shift struct {
+	ShiftID          string `gorm:"primaryKey"`
+	DeliveryPersonID string
+	StartedAt        int64
+	EndedAt          int64
+	StartLocation    *Point `gorm:"column:start_location"`
+	EndLocation      *Point `gorm:"column:end_location"`
+}
+
+// ProofOfDelivery is the evidence captured when an order is handed over.
+// DistanceMeters is how far from the drop-off the delivery person was.
+type ProofOfDelivery struct {
+	OrderID          string `gorm:"primaryKey"`
+	DeliveryPersonID string
+	PhotoHash        string
+	PhotoRef         string
+	RecipientName    string
+	Signature        []byte
+	Location         *Point `gorm:"column:location"`
+	DistanceMeters   float64
+	CapturedAt       int64
+}
+
+// DeliveryPin is the one-time code the customer gives the delivery person at
+// the door. VerifiedAt is set once the right PIN is entered or ops override
+// the check.
+type DeliveryPin struct {
+	OrderID        string `gorm:"primaryKey"`
+	Pin            string
+	Attempts       int
+	VerifiedAt     int64
+	OverriddenBy   string
+	OverrideReason string
+	CreatedAt      int64
+}
+
+// DeliveryException is one failed delivery attempt and what was decided
+// about it.
+type DeliveryException struct {
+	ExceptionID      string `gorm:"primaryKey"`
+	OrderID          string
+	DeliveryPersonID string
+	Reason           string
+	Note             string
+	Location         *Point `gorm:"column:location"`
+	Attempt          int
+	Action           string
+	ReportedAt       int64
+}
+
+// SLATarget is how long orders of a priority may take from being placed to
+// being delivered in a zone. An empty ZoneID or Priority applies to every
+// zone or priority.
+type SLATarget struct {
+	ZoneID        string `gorm:"primaryKey"`
+	Priority      string `gorm:"primaryKey"`
+	TargetSeconds int64
+	UpdatedAt     int64
+}
+
+// OutboxEvent is a domain event waiting to be published. It is written in
+// the same transaction as the change it describes. PublishedAt is zero until
+// the event has been published; failed attempts are retried from
+// NextAttemptAt.
+type OutboxEvent struct {
+	ID            int64 `gorm:"primaryKey"`
+	EventID       string
+	AggregateID   string
+	EventType     string
+	Payload       string
+	Attempts      int
+	NextAttemptAt int64
+	LastError     string
+	CreatedAt     int64
+	PublishedAt   int64
+}
+
+// DeadLetterEvent is an outbox event that could not be published.
+type DeadLetterEvent struct {
+	EventID     string `gorm:"primaryKey"`
+	AggregateID string
+	EventType   string
+	Payload     string
+	Attempts    int
+	LastError   string
+	CreatedAt   int64
+	FailedAt    int64
+}
+
+// WebhookSubscription is a partner endpoint that receives order events.
+// EventTypes is a comma separated list; empty means every event. A
+// subscription is disabled once ConsecutiveFailures reaches the configured
+// limit.
+type WebhookSubscription struct {
+	SubscriptionID      string `gorm:"primaryKey"`
+	URL                 string `gorm:"column:url"`
+	EventTypes          string
+	Secret              string
+	Active              bool
+	ConsecutiveFailures int
+	DisabledAt          int64
+	CreatedAt           int64
+	UpdatedAt           int64
+}
+
+// WebhookDelivery is one event on its way to one subscription, and the log
+// of how sending it went.
+type WebhookDelivery struct {
+	DeliveryID     string `gorm:"primaryKey"`
+	SubscriptionID string
+	EventID        string
+	EventType      string
+	Payload        string
+	Status         string
+	Attempts       int
+	NextAttemptAt  int64
+	LastStatusCode int
+	LastError      string
+	CreatedAt      int64
+	DeliveredAt    int64
+}
+
+// ProcessedEvent records an order-created event that has been handled, so a
+// redelivery of it is ignored.
+type ProcessedEvent struct {
+	EventID     string `gorm:"primaryKey"`
+	ProcessedAt int64
+}
+
+// InboxEvent is an order-created event queued by an upstream service.
+// Payload is the event as protobuf JSON. ProcessedAt is set once the order
+// has been created; DeadAt is set instead if the event was given up on.
+type InboxEvent struct {
+	ID            int64 `gorm:"primaryKey"`
+	Payload       string
+	Attempts      int
+	NextAttemptAt int64
+	LastError     string
+	CreatedAt     int64
+	ProcessedAt   int64
+	DeadAt        int64
+}
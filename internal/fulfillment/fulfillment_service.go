@@ -2,86 +2,275 @@ package fulfillment
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"fullfillment-service/internal/auth"
 	pb "fullfillment-service/proto"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/gorm"
 )
 
 type OrderService struct {
-	db *gorm.DB
+	db        *gorm.DB
+	cfg       Config
+	now       func() time.Time
+	notifier  Notifier
+	publisher EventPublisher
 	pb.UnimplementedFulfillmentServiceServer
 }
 
-func NewService(db *gorm.DB) *OrderService {
-	return &OrderService{db: db}
+func NewService(db *gorm.DB, opts ...Option) *OrderService {
+	s := &OrderService{db: db, cfg: DefaultConfig(), now: time.Now, notifier: LogNotifier{}, publisher: LogPublisher{}}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-func (s *OrderService) AssignOrder(ctx context.Context, req *pb.AssignOrderRequest) (*pb.AssignOrderResponse, error) {
-	var nearestDeliveryPerson DeliveryPerson
+type commitHooksKey struct{}
 
-	err := s.db.Model(&DeliveryPerson{}).
-		Where("status = ?", "AVAILABLE").
-		Order("ST_Distance(location::geometry, ST_Point(40.748817, -73.985428)::geometry) ASC").
-		Limit(1).
-		First(&nearestDeliveryPerson).Error
+type transactionKey struct{}
+
+// withTransaction makes transaction join tx instead of starting a
+// transaction of its own, so that the caller's writes and fn's commit or
+// roll back together.
+func withTransaction(ctx context.Context, tx *gorm.DB) context.Context {
+	return context.WithValue(ctx, transactionKey{}, tx)
+}
 
-	if err != nil || nearestDeliveryPerson.DeliveryPersonID == "" {
-		return &pb.AssignOrderResponse{Status: "FAILED"}, fmt.Errorf("no available delivery person found")
+// transaction runs fn in a database transaction and then, if it committed,
+// the hooks fn registered with afterCommit. Within a transaction joined with
+// withTransaction, fn runs in a savepoint instead, and its hooks wait for the
+// outer transaction to commit.
+func (s *OrderService) transaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	var hooks []func()
+	if outer, ok := ctx.Value(transactionKey{}).(*gorm.DB); ok {
+		nested := outer.WithContext(context.WithValue(outer.Statement.Context, commitHooksKey{}, &hooks))
+		if err := nested.Transaction(fn); err != nil {
+			return err
+		}
+		for _, hook := range hooks {
+			afterCommit(outer, hook)
+		}
+		return nil
 	}
+	ctx = context.WithValue(ctx, commitHooksKey{}, &hooks)
+	if err := s.db.WithContext(ctx).Transaction(fn); err != nil {
+		return err
+	}
+	for _, hook := range hooks {
+		hook()
+	}
+	return nil
+}
 
-	order := Order{
-		OrderID:          req.OrderId,
-		DeliveryPersonID: nearestDeliveryPerson.DeliveryPersonID,
-		Status:           "ASSIGNED",
+// afterCommit runs hook once the transaction tx belongs to commits. Outside
+// a transaction started by transaction, writes are already committed, so
+// hook runs straight away.
+func afterCommit(tx *gorm.DB, hook func()) {
+	if hooks, ok := tx.Statement.Context.Value(commitHooksKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, hook)
+		return
 	}
-	if err := s.db.Create(&order).Error; err != nil {
-		return &pb.AssignOrderResponse{Status: "FAILED"}, err
+	hook()
+}
+
+func (s *OrderService) AssignOrder(ctx context.Context, req *pb.AssignOrderRequest) (*pb.AssignOrderResponse, error) {
+	return s.assignOrder(ctx, req, assignOptions{})
+}
+
+// assignOptions adapts assignOrder to callers other than AssignOrder.
+type assignOptions struct {
+	// claim, if set, is called first, in the same transaction, and the
+	// order is only created if it returns nil.
+	claim func(tx *gorm.DB) error
+	// keepUnassigned keeps an order nobody can take yet as UNASSIGNED, for
+	// RedispatchUnassigned to offer later, rather than failing.
+	keepUnassigned bool
+}
+
+// assignOrder creates and dispatches the order in req.
+func (s *OrderService) assignOrder(ctx context.Context, req *pb.AssignOrderRequest, opts assignOptions) (_ *pb.AssignOrderResponse, err error) {
+	defer func() {
+		if err != nil && !errors.Is(err, errDuplicateEvent) {
+			assignmentFailures.WithLabelValues(assignmentFailureReason(err)).Inc()
+		}
+	}()
+	if _, ok := packageSizes[req.PackageSize]; !ok {
+		return &pb.AssignOrderResponse{Status: "FAILED"}, status.Errorf(codes.InvalidArgument, "unknown package size %q", req.PackageSize)
+	}
+	priority := req.Priority
+	if priority == "" {
+		priority = PriorityStandard
+	}
+	if _, ok := priorityRanks[priority]; !ok {
+		return &pb.AssignOrderResponse{Status: "FAILED"}, status.Errorf(codes.InvalidArgument, "unknown priority %q", req.Priority)
 	}
+	if req.DeliveryWindowStart != 0 || req.DeliveryWindowEnd != 0 {
+		if req.DeliveryWindowEnd <= req.DeliveryWindowStart || req.DeliveryWindowEnd <= s.now().Unix() {
+			return &pb.AssignOrderResponse{Status: "FAILED"}, status.Error(codes.InvalidArgument, "invalid delivery window")
+		}
+	}
+
+	var offer *Offer
+	var order Order
+	err = s.transaction(ctx, func(tx *gorm.DB) error {
+		if opts.claim != nil {
+			if err := opts.claim(tx); err != nil {
+				return err
+			}
+		}
+		order = Order{
+			OrderID:         req.OrderId,
+			Status:          OrderStatusOffered,
+			WeightKg:        req.WeightKg,
+			VolumeLiters:    req.VolumeLiters,
+			PackageSize:     req.PackageSize,
+			Pickup:          pointFromProto(req.Pickup),
+			Dropoff:         pointFromProto(req.Dropoff),
+			WindowStart:     req.DeliveryWindowStart,
+			WindowEnd:       req.DeliveryWindowEnd,
+			Priority:        priority,
+			StatusChangedAt: s.now().Unix(),
+		}
+		if len(vehiclesFor(&order)) == 0 {
+			return status.Error(codes.InvalidArgument, "order is too large or heavy for any vehicle")
+		}
+		if order.Pickup != nil {
+			zoneID, err := zoneAt(tx, *order.Pickup)
+			if err != nil {
+				return err
+			}
+			order.ZoneID = zoneID
+		}
+		if order.WindowEnd != 0 {
+			order.DispatchAt = s.dispatchAt(&order)
+			if order.DispatchAt > s.now().Unix() {
+				order.Status = OrderStatusScheduled
+			}
+		}
+		// delivery_person_id stays NULL until someone accepts the order;
+		// an empty string would fail its foreign key.
+		if err := tx.Omit("delivery_person_id").Create(&order).Error; err != nil {
+			return err
+		}
+		if order.Status == OrderStatusScheduled {
+			return nil
+		}
 
-	nearestDeliveryPerson.Status = "BUSY"
-	if err := s.db.Save(&nearestDeliveryPerson).Error; err != nil {
+		var err error
+		offer, err = s.offerNext(tx, &order)
+		if err != nil {
+			return err
+		}
+		if offer == nil {
+			if opts.keepUnassigned {
+				return s.setOrderStatus(tx, &order, OrderStatusUnassigned)
+			}
+			return errNoDeliveryPerson
+		}
+		return nil
+	})
+	if err != nil {
 		return &pb.AssignOrderResponse{Status: "FAILED"}, err
 	}
+	if order.Status == OrderStatusScheduled {
+		return &pb.AssignOrderResponse{Status: OrderStatusScheduled, DispatchAt: order.DispatchAt}, nil
+	}
+	if offer == nil {
+		return &pb.AssignOrderResponse{Status: OrderStatusUnassigned}, nil
+	}
 
-	return &pb.AssignOrderResponse{Status: "ASSIGNED"}, nil
+	return &pb.AssignOrderResponse{
+		Status:           OrderStatusOffered,
+		OfferId:          offer.OfferID,
+		DeliveryPersonId: offer.DeliveryPersonID,
+		OfferExpiresAt:   offer.ExpiresAt,
+		PickupEta:        order.PickupETA,
+		DeliveryEta:      order.DeliveryETA,
+	}, nil
 }
 
 func (s *OrderService) GetOrderStatus(ctx context.Context, req *pb.GetOrderStatusRequest) (*pb.GetOrderStatusResponse, error) {
 	var order Order
-	if err := s.db.First(&order, "order_id = ?", req.OrderId).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&order, "order_id = ?", req.OrderId).Error; err != nil {
 		return nil, fmt.Errorf("order not found")
 	}
 
 	return &pb.GetOrderStatusResponse{
-		OrderId: req.OrderId,
-		Status:  order.Status,
+		OrderId:             req.OrderId,
+		Status:              order.Status,
+		PickupEta:           order.PickupETA,
+		DeliveryEta:         order.DeliveryETA,
+		ZoneId:              order.ZoneID,
+		NeedsAttention:      order.NeedsAttention,
+		AttentionReason:     order.AttentionReason,
+		DeliveryWindowStart: order.WindowStart,
+		DeliveryWindowEnd:   order.WindowEnd,
+		WindowOutcome:       order.WindowOutcome,
+		Priority:            order.Priority,
+		SlaStatus:           order.SLAStatus,
+		SlaDueAt:            order.SLADueAt,
 	}, nil
 }
 
-func (s *OrderService) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
-	var order Order
-	if err := s.db.First(&order, "order_id = ?", req.OrderId).Error; err != nil {
-		return nil, fmt.Errorf("order not found")
-	}
+// statusUpdates are the moves UpdateOrderStatus may make from each status.
+var statusUpdates = map[string][]string{
+	OrderStatusAssigned: {OrderStatusInProgress},
+}
 
-	order.Status = req.Status
-	if err := s.db.Save(&order).Error; err != nil {
-		return nil, fmt.Errorf("failed to update order status")
-	}
+// statusRPCs are the statuses only a dedicated RPC may move an order to,
+// because it checks or records what the move needs.
+var statusRPCs = map[string]string{
+	OrderStatusScheduled: "AssignOrder",
+	OrderStatusOffered:   "AssignOrder",
+	OrderStatusAssigned:  "AcceptOffer",
+	OrderStatusDelivered: "CompleteDelivery",
+	OrderStatusReturning: "ReportDeliveryException",
+	OrderStatusReturned:  "CompleteReturn",
+}
 
-	var deliveryPerson DeliveryPerson
-	if err := s.db.First(&deliveryPerson, "delivery_person_id = ?", order.DeliveryPersonID).Error; err != nil {
-		return nil, fmt.Errorf("failed to find delivery person")
+func (s *OrderService) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrderStatusRequest) (*pb.UpdateOrderStatusResponse, error) {
+	if rpc, ok := statusRPCs[req.Status]; ok {
+		return nil, status.Errorf(codes.FailedPrecondition, "use %s to move an order to %s", rpc, req.Status)
 	}
 
-	if req.Status == "DELIVERED" {
-		deliveryPerson.Status = "AVAILABLE"
-	} else if req.Status == "IN_PROGRESS" {
-		deliveryPerson.Status = "BUSY"
-	}
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var order Order
+		if err := tx.First(&order, "order_id = ?", req.OrderId).Error; err != nil {
+			return fmt.Errorf("order not found")
+		}
+		if driver, ok := auth.DeliveryPersonScope(ctx); ok && order.DeliveryPersonID != driver {
+			return status.Error(codes.PermissionDenied, "order is assigned to another delivery person")
+		}
+		if order.Status == OrderStatusScheduled {
+			return status.Error(codes.FailedPrecondition, "order is scheduled and has not been dispatched yet")
+		}
+		if !slices.Contains(statusUpdates[order.Status], req.Status) {
+			return status.Errorf(codes.FailedPrecondition, "order is %s and cannot move to %s", order.Status, req.Status)
+		}
 
-	if err := s.db.Save(&deliveryPerson).Error; err != nil {
-		return nil, fmt.Errorf("failed to update delivery person status")
+		if err := s.setOrderStatus(tx, &order, req.Status); err != nil {
+			return fmt.Errorf("failed to update order status")
+		}
+
+		if order.DeliveryPersonID == "" {
+			return nil
+		}
+		if err := refreshDriverStatus(tx, order.DeliveryPersonID); err != nil {
+			return fmt.Errorf("failed to update delivery person status")
+		}
+		if err := s.planRoute(tx, order.DeliveryPersonID); err != nil {
+			return fmt.Errorf("failed to update delivery route")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return &pb.UpdateOrderStatusResponse{Status: "UPDATED"}, nil
@@ -89,15 +278,17 @@ func (s *OrderService) UpdateOrderStatus(ctx context.Context, req *pb.UpdateOrde
 
 func (s *OrderService) GetOrdersByDeliveryPerson(ctx context.Context, req *pb.GetOrdersByDeliveryPersonRequest) (*pb.GetOrdersByDeliveryPersonResponse, error) {
 	var orders []Order
-	if err := s.db.Where("delivery_person_id = ?", req.DeliveryPersonId).Find(&orders).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("delivery_person_id = ?", req.DeliveryPersonId).Order(priorityOrder).Find(&orders).Error; err != nil {
 		return nil, err
 	}
 
 	var protoOrders []*pb.Order
 	for _, order := range orders {
 		protoOrders = append(protoOrders, &pb.Order{
-			OrderId: order.OrderID,
-			Status:  order.Status,
+			OrderId:  order.OrderID,
+			Status:   order.Status,
+			BatchId:  order.BatchID,
+			Priority: order.Priority,
 		})
 	}
 
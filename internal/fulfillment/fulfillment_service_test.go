@@ -3,14 +3,21 @@ package fulfillment
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	"sync"
 	"testing"
+	"time"
 
+	"fullfillment-service/internal/auth"
 	pb "fullfillment-service/proto"
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, *sql.DB) {
@@ -29,54 +36,117 @@ func setupMockDB(t *testing.T) (*gorm.DB, sqlmock.Sqlmock, *sql.DB) {
 	return gormDB, mock, db
 }
 
+// insertArgs builds the argument list for inserting model, checking the
+// leading column values and accepting anything for the remaining columns.
+func insertArgs(model interface{}, leading ...driver.Value) []driver.Value {
+	s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		panic(err)
+	}
+	args := append([]driver.Value(nil), leading...)
+	for len(args) < len(s.DBNames) {
+		args = append(args, sqlmock.AnyArg())
+	}
+	return args
+}
+
+// orderInsertArgs are the arguments of the INSERT made by AssignOrder, which
+// leaves delivery_person_id out so it is NULL.
+func orderInsertArgs(leading ...driver.Value) []driver.Value {
+	args := insertArgs(&Order{}, leading...)
+	return args[:len(args)-1]
+}
+
+// expectCandidateQuery expects the nearest-candidate lookup within radius
+// meters made while offering orderID and answers it with rows.
+func expectCandidateQuery(mock sqlmock.Sqlmock, orderID string, radius float64, rows *sqlmock.Rows) {
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE status = \$1 AND delivery_person_id NOT IN \(SELECT "delivery_person_id" FROM "offers" WHERE order_id = \$2 OR status = \$3\) AND ST_DWithin\(location, \$4::geography, \$5\) AND \(EXISTS \(SELECT 1 FROM shifts .*\)\) AND delivery_people\.vehicle_type IN \(\$6,\$7,\$8,\$9\) AND \(max_weight_kg = 0 .*\) AND \(max_volume_liters = 0 .*\) ORDER BY ST_Distance\(location, \$18::geography\) LIMIT \$19`).
+		WithArgs("AVAILABLE", orderID, "PENDING", sqlmock.AnyArg(), radius,
+			"BIKE", "SCOOTER", "CAR", "VAN",
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg(),
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnRows(rows)
+}
+
+// expectNoCandidates expects every dispatch ring of the default config to be
+// searched for orderID without finding anyone.
+func expectNoCandidates(mock sqlmock.Sqlmock, orderID string) {
+	for _, radius := range []float64{1000, 3000, 5000} {
+		expectCandidateQuery(mock, orderID, radius, sqlmock.NewRows([]string{"delivery_person_id"}))
+	}
+}
+
+// expectRoutePlan expects the route for deliveryPersonID to be replanned
+// from the active orders in orders.
+func expectRoutePlan(mock sqlmock.Sqlmock, deliveryPersonID string, orders *sqlmock.Rows) {
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1`).
+		WithArgs(deliveryPersonID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow(deliveryPersonID, "BUSY"))
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE delivery_person_id = \$1 AND status IN \(\$2,\$3,\$4\) ORDER BY created_at`).
+		WithArgs(deliveryPersonID, "ASSIGNED", "IN_PROGRESS", "RETURNING").
+		WillReturnRows(orders)
+	mock.ExpectExec(`DELETE FROM "route_stops" WHERE delivery_person_id = \$1`).
+		WithArgs(deliveryPersonID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+}
+
+// expectDriverStatusRefresh expects the load-based status recomputation for
+// deliveryPersonID.
+func expectDriverStatusRefresh(mock sqlmock.Sqlmock, deliveryPersonID string) *sqlmock.ExpectedExec {
+	return mock.ExpectExec(`UPDATE "delivery_people" SET "status"=CASE WHEN \(SELECT COUNT\(\*\) FROM "orders" WHERE delivery_person_id = \$1 AND status IN \(\$2,\$3,\$4\)\) >= capacity THEN \$5 ELSE \$6 END WHERE status IN \(\$7,\$8\) AND "delivery_person_id" = \$9`).
+		WithArgs(deliveryPersonID, "ASSIGNED", "IN_PROGRESS", "RETURNING", "BUSY", "AVAILABLE", "AVAILABLE", "BUSY", deliveryPersonID)
+}
+
 func TestAssignOrder(t *testing.T) {
 	db, mock, sqlDB := setupMockDB(t)
 	defer sqlDB.Close()
 
-	service := NewService(db)
-
-	t.Run("Success - Assign Order", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE status = \$1 ORDER BY ST_Distance`).
-			WithArgs("AVAILABLE").
-			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "location"}).
-				AddRow("dp1", "AVAILABLE", "(37.7749,-122.4194)"))
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
 
+	t.Run("Success - Offer Order To Nearest Delivery Person", func(t *testing.T) {
 		mock.ExpectBegin()
-		mock.ExpectExec(`INSERT INTO "orders"`).
-			WithArgs("order1", "dp1", "ASSIGNED").
+		mock.ExpectExec(`INSERT INTO "orders" \("order_id","status",`).
+			WithArgs(orderInsertArgs("order1", "OFFERED", 2.5, 10.0)...).
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE "delivery_person_id" = \$2`).
-			WithArgs("BUSY", "dp1").
+		expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status", "location"}).
+			AddRow("dp1", "AVAILABLE", "0101000020E610000003249A40117F52C02CD8463CD95F4440"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order1", "dp1", "PENDING", "", "", now.Unix()+30)...).
 			WillReturnResult(sqlmock.NewResult(1, 1))
 		mock.ExpectCommit()
 
-		req := &pb.AssignOrderRequest{OrderId: "order1"}
+		req := &pb.AssignOrderRequest{OrderId: "order1", WeightKg: 2.5, VolumeLiters: 10}
 		resp, err := service.AssignOrder(context.Background(), req)
 
 		assert.NoError(t, err)
-		assert.Equal(t, "ASSIGNED", resp.Status)
+		assert.Equal(t, "OFFERED", resp.Status)
+		assert.Equal(t, "dp1", resp.DeliveryPersonId)
+		assert.NotEmpty(t, resp.OfferId)
+		assert.Equal(t, now.Unix()+30, resp.OfferExpiresAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Failure - No Available Delivery Person", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE status = \$1 ORDER BY ST_Distance`).
-			WithArgs("AVAILABLE").
-			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status"}))
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WithArgs(orderInsertArgs("order2", "OFFERED", 0.0, 0.0)...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectNoCandidates(mock, "order2")
+		mock.ExpectRollback()
 
 		req := &pb.AssignOrderRequest{OrderId: "order2"}
 		resp, err := service.AssignOrder(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Equal(t, "FAILED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Failure - Database Error on Create", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE status = \$1 ORDER BY ST_Distance`).
-			WithArgs("AVAILABLE").
-			WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp1", "AVAILABLE"))
-
 		mock.ExpectBegin()
 		mock.ExpectExec(`INSERT INTO "orders"`).
-			WithArgs("order3", "dp1", "ASSIGNED").
+			WithArgs(orderInsertArgs("order3", "OFFERED", 0.0, 0.0)...).
 			WillReturnError(errors.New("some database error"))
 		mock.ExpectRollback()
 
@@ -85,6 +155,7 @@ func TestAssignOrder(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Equal(t, "FAILED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -125,52 +196,86 @@ func TestUpdateOrderStatus(t *testing.T) {
 
 	service := NewService(db)
 
-	t.Run("Success - Update Order Status to Delivered", func(t *testing.T) {
+	t.Run("Success - Update Order Status to In Progress", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 ORDER BY "orders"."order_id" LIMIT \$2`).
 			WithArgs("order1", 1).
 			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "ASSIGNED"))
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1, "updated_at"=\$2 WHERE "order_id" = \$3`).
-			WithArgs("DELIVERED", sqlmock.AnyArg(), "order1").
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("IN_PROGRESS", sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-
-		mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE "delivery_person_id" = \$2`).
-			WithArgs("AVAILABLE", "dp1").
+		expectEvent(mock, "order.status_changed", "order1")
+		expectDriverStatusRefresh(mock, "dp1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
 		mock.ExpectCommit()
 
-		req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "DELIVERED"}
+		req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "IN_PROGRESS"}
 		resp, err := service.UpdateOrderStatus(context.Background(), req)
 
 		assert.NoError(t, err)
 		assert.Equal(t, "UPDATED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Delivered Requires Proof", func(t *testing.T) {
+		req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "DELIVERED"}
+		resp, err := service.UpdateOrderStatus(context.Background(), req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("Failure - Statuses With Their Own RPC", func(t *testing.T) {
+		for _, to := range []string{"SCHEDULED", "OFFERED", "ASSIGNED", "RETURNING", "RETURNED"} {
+			req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: to}
+			resp, err := service.UpdateOrderStatus(context.Background(), req)
+
+			assert.Nil(t, resp)
+			assert.Equal(t, codes.FailedPrecondition, status.Code(err), to)
+		}
+	})
+
+	t.Run("Failure - Transition Not Allowed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+			WithArgs("order1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "DELIVERED"))
+		mock.ExpectRollback()
+
+		req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "IN_PROGRESS"}
+		resp, err := service.UpdateOrderStatus(context.Background(), req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
 	t.Run("Failure - Order Not Found", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
-			WithArgs("order2").
+			WithArgs("order2", 1).
 			WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}))
+		mock.ExpectRollback()
 
-		req := &pb.UpdateOrderStatusRequest{OrderId: "order2", Status: "DELIVERED"}
+		req := &pb.UpdateOrderStatusRequest{OrderId: "order2", Status: "IN_PROGRESS"}
 		resp, err := service.UpdateOrderStatus(context.Background(), req)
 
 		assert.Error(t, err)
 		assert.Nil(t, resp)
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 
-	t.Run("Failure - Delivery Person Not Found", func(t *testing.T) {
+	t.Run("Failure - Delivery Person Status Update Fails", func(t *testing.T) {
+		mock.ExpectBegin()
 		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
-			WithArgs("order1").
+			WithArgs("order1", 1).
 			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "ASSIGNED"))
-
-		mock.ExpectBegin()
-		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1, "updated_at"=\$2 WHERE "order_id" = \$3`).
-			WithArgs("IN_PROGRESS", sqlmock.AnyArg(), "order1").
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("IN_PROGRESS", sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
 			WillReturnResult(sqlmock.NewResult(1, 1))
-		mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE "delivery_person_id" = \$2`).
-			WithArgs("BUSY", "dp1").
+		expectEvent(mock, "order.status_changed", "order1")
+		expectDriverStatusRefresh(mock, "dp1").
 			WillReturnError(errors.New("failed to find delivery person"))
 		mock.ExpectRollback()
 
@@ -179,6 +284,23 @@ func TestUpdateOrderStatus(t *testing.T) {
 
 		assert.Error(t, err)
 		assert.Nil(t, resp)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Driver Updates Another Driver's Order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+			WithArgs("order1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "ASSIGNED"))
+		mock.ExpectRollback()
+
+		ctx := auth.WithDeliveryPersonScope(context.Background(), "dp2")
+		req := &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "IN_PROGRESS"}
+		resp, err := service.UpdateOrderStatus(ctx, req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
 	})
 }
 
@@ -189,9 +311,9 @@ func TestGetOrdersByDeliveryPerson(t *testing.T) {
 	service := NewService(db)
 
 	t.Run("Success - Get Orders by Delivery Person", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE delivery_person_id = \$1`).
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE delivery_person_id = \$1 ORDER BY CASE priority WHEN 'MEDICAL' THEN 3 WHEN 'VIP' THEN 2 WHEN 'EXPRESS' THEN 1 ELSE 0 END DESC`).
 			WithArgs("dp1").
-			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "DELIVERED"))
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "priority"}).AddRow("order1", "dp1", "DELIVERED", "EXPRESS"))
 
 		req := &pb.GetOrdersByDeliveryPersonRequest{DeliveryPersonId: "dp1"}
 		resp, err := service.GetOrdersByDeliveryPerson(context.Background(), req)
@@ -200,6 +322,7 @@ func TestGetOrdersByDeliveryPerson(t *testing.T) {
 		assert.Len(t, resp.Orders, 1)
 		assert.Equal(t, "order1", resp.Orders[0].OrderId)
 		assert.Equal(t, "DELIVERED", resp.Orders[0].Status)
+		assert.Equal(t, "EXPRESS", resp.Orders[0].Priority)
 	})
 
 	t.Run("Failure - No Orders Found", func(t *testing.T) {
@@ -0,0 +1,95 @@
+package fulfillment
+
+import (
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	pb "fullfillment-service/proto"
+	"math"
+)
+
+const (
+	wkbPoint  = 1
+	ewkbSRID  = 0x20000000
+	sridWGS84 = 4326
+
+	earthRadiusMeters = 6371000
+)
+
+// distanceMeters is the great-circle distance between two points.
+func distanceMeters(a, b Point) float64 {
+	lat1 := a.Lat * math.Pi / 180
+	lat2 := b.Lat * math.Pi / 180
+	dLat := (b.Lat - a.Lat) * math.Pi / 180
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusMeters * math.Asin(math.Sqrt(h))
+}
+
+// Value stores the point as EWKT so PostGIS can cast it into a geography column.
+func (p Point) Value() (driver.Value, error) {
+	return fmt.Sprintf("SRID=%d;POINT(%v %v)", sridWGS84, p.Lng, p.Lat), nil
+}
+
+// Scan reads the (E)WKB representation PostGIS returns for geometry and
+// geography columns, either raw or hex encoded.
+func (p *Point) Scan(src interface{}) error {
+	var raw []byte
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		raw = v
+	case string:
+		raw = []byte(v)
+	default:
+		return fmt.Errorf("unsupported point type %T", src)
+	}
+
+	if decoded, err := hex.DecodeString(string(raw)); err == nil {
+		raw = decoded
+	}
+	if len(raw) < 5 {
+		return fmt.Errorf("invalid point: too short")
+	}
+
+	var order binary.ByteOrder = binary.BigEndian
+	if raw[0] == 1 {
+		order = binary.LittleEndian
+	}
+	geomType := order.Uint32(raw[1:5])
+	raw = raw[5:]
+	if geomType&ewkbSRID != 0 {
+		if len(raw) < 4 {
+			return fmt.Errorf("invalid point: missing SRID")
+		}
+		raw = raw[4:]
+	}
+	if geomType&0xff != wkbPoint {
+		return fmt.Errorf("invalid point: unexpected geometry type %d", geomType&0xff)
+	}
+	if len(raw) < 16 {
+		return fmt.Errorf("invalid point: missing coordinates")
+	}
+
+	p.Lng = math.Float64frombits(order.Uint64(raw[0:8]))
+	p.Lat = math.Float64frombits(order.Uint64(raw[8:16]))
+	return nil
+}
+
+func pointFromProto(l *pb.Location) *Point {
+	if l == nil {
+		return nil
+	}
+	return &Point{Lat: l.Lat, Lng: l.Lng}
+}
+
+func pointToProto(p *Point) *pb.Location {
+	if p == nil {
+		return nil
+	}
+	return &pb.Location{Lat: p.Lat, Lng: p.Lng}
+}
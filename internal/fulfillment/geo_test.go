@@ -0,0 +1,43 @@
+package fulfillment
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPointScanEWKB(t *testing.T) {
+	var p Point
+	err := p.Scan("0101000020E610000003249A40117F52C02CD8463CD95F4440")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 40.748817, p.Lat)
+	assert.Equal(t, -73.985428, p.Lng)
+}
+
+func TestPointScanBigEndianWKB(t *testing.T) {
+	raw, _ := hex.DecodeString("0000000001C0527F11409A240340445FD93C46D82C")
+
+	var p Point
+	err := p.Scan(raw)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 40.748817, p.Lat)
+	assert.Equal(t, -73.985428, p.Lng)
+}
+
+func TestPointScanFailure(t *testing.T) {
+	var p Point
+
+	assert.Error(t, p.Scan("01"))
+	assert.Error(t, p.Scan(42))
+	assert.Error(t, p.Scan("0103000020E6100000000000000000000000000000000000000000000000"))
+}
+
+func TestPointValue(t *testing.T) {
+	value, err := Point{Lat: 40.748817, Lng: -73.985428}.Value()
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SRID=4326;POINT(-73.985428 40.748817)", value)
+}
@@ -0,0 +1,135 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// AttentionDriverUnresponsive flags orders whose delivery person has stopped
+// reporting in.
+const AttentionDriverUnresponsive = "DRIVER_UNRESPONSIVE"
+
+// driverOfflineExpireReason is recorded on offers withdrawn because the
+// delivery person stopped reporting in before answering.
+const driverOfflineExpireReason = "driver offline"
+
+func (s *OrderService) Heartbeat(ctx context.Context, req *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	var driver DeliveryPerson
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.markSeen(tx, req.DeliveryPersonId); err != nil {
+			return err
+		}
+		return tx.Select("status", "last_seen_at").
+			First(&driver, "delivery_person_id = ?", req.DeliveryPersonId).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.HeartbeatResponse{Status: driver.Status, LastSeenAt: driver.LastSeenAt}, nil
+}
+
+// markSeen records that a delivery person has just been heard from. Drivers
+// the sweeper took offline in the middle of a shift are brought back online
+// and their orders are no longer flagged.
+func (s *OrderService) markSeen(tx *gorm.DB, deliveryPersonID string) error {
+	result := tx.Model(&DeliveryPerson{DeliveryPersonID: deliveryPersonID}).Update("last_seen_at", s.now().Unix())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return status.Error(codes.NotFound, "delivery person not found")
+	}
+
+	result = tx.Model(&DeliveryPerson{}).
+		Where("delivery_person_id = ? AND status IN ?", deliveryPersonID, []string{DeliveryPersonStale, DeliveryPersonOffline}).
+		Scopes(onShift).
+		Update("status", DeliveryPersonAvailable)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&Order{}).
+		Where("delivery_person_id = ? AND attention_reason = ?", deliveryPersonID, AttentionDriverUnresponsive).
+		Updates(map[string]interface{}{"needs_attention": false, "attention_reason": ""}).Error; err != nil {
+		return err
+	}
+	return refreshDriverStatus(tx, deliveryPersonID)
+}
+
+// SweepSilentDrivers marks delivery people who have not been heard from in
+// StaleAfter as STALE, and in OfflineAfter as OFFLINE, flags their active
+// orders for ops and passes their pending offers on. It returns the number of delivery people whose status
+// changed.
+func (s *OrderService) SweepSilentDrivers(ctx context.Context) (int, error) {
+	now := s.now()
+	var changed int64
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		// Offline first, so that a driver silent for longer than both goes
+		// straight to OFFLINE and is counted once.
+		offline := tx.Model(&DeliveryPerson{}).
+			Where("status IN ? AND last_seen_at < ?", []string{DeliveryPersonAvailable, DeliveryPersonBusy, DeliveryPersonStale}, now.Add(-s.cfg.OfflineAfter).Unix()).
+			Update("status", DeliveryPersonOffline)
+		if offline.Error != nil {
+			return offline.Error
+		}
+		stale := tx.Model(&DeliveryPerson{}).
+			Where("status IN ? AND last_seen_at < ?", []string{DeliveryPersonAvailable, DeliveryPersonBusy}, now.Add(-s.cfg.StaleAfter).Unix()).
+			Update("status", DeliveryPersonStale)
+		if stale.Error != nil {
+			return stale.Error
+		}
+		changed = stale.RowsAffected + offline.RowsAffected
+
+		silent := tx.Model(&DeliveryPerson{}).
+			Select("delivery_person_id").
+			Where("status IN ?", []string{DeliveryPersonStale, DeliveryPersonOffline})
+		if err := tx.Model(&Order{}).
+			Where("status IN ? AND needs_attention = ? AND delivery_person_id IN (?)", activeOrderStatuses, false, silent).
+			Updates(map[string]interface{}{"needs_attention": true, "attention_reason": AttentionDriverUnresponsive}).Error; err != nil {
+			return err
+		}
+
+		// A silent driver will not answer, so pass their offers on now
+		// rather than waiting for them to time out.
+		var pending []Offer
+		if err := tx.Where("status = ? AND delivery_person_id IN (?)", OfferPending, silent).Find(&pending).Error; err != nil {
+			return err
+		}
+		for i := range pending {
+			if err := s.closeOffer(tx, &pending[i], OfferExpired, driverOfflineExpireReason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return int(changed), err
+}
+
+// RunDriverSweeper calls SweepSilentDrivers every interval until ctx is
+// cancelled.
+func (s *OrderService) RunDriverSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.SweepSilentDrivers(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to sweep silent delivery people", "error", err)
+			}
+		}
+	}
+}
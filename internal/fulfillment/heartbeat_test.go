@@ -0,0 +1,110 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expectMarkSeen expects deliveryPersonID's last-seen time to be bumped to
+// seenAt, with restored saying whether they come back online.
+func expectMarkSeen(mock sqlmock.Sqlmock, deliveryPersonID string, seenAt int64, restored bool) {
+	mock.ExpectExec(`UPDATE "delivery_people" SET "last_seen_at"=\$1 WHERE "delivery_person_id" = \$2`).
+		WithArgs(seenAt, deliveryPersonID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	affected := int64(0)
+	if restored {
+		affected = 1
+	}
+	mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE \(delivery_person_id = \$2 AND status IN \(\$3,\$4\)\) AND \(EXISTS \(SELECT 1 FROM shifts .*\)\)`).
+		WithArgs("AVAILABLE", deliveryPersonID, "STALE", "OFFLINE").
+		WillReturnResult(sqlmock.NewResult(0, affected))
+	if !restored {
+		return
+	}
+	mock.ExpectExec(`UPDATE "orders" SET "attention_reason"=\$1,"needs_attention"=\$2,"updated_at"=\$3 WHERE delivery_person_id = \$4 AND attention_reason = \$5`).
+		WithArgs("", false, sqlmock.AnyArg(), deliveryPersonID, "DRIVER_UNRESPONSIVE").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectDriverStatusRefresh(mock, deliveryPersonID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+}
+
+func TestHeartbeat(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Stale Driver Comes Back Online", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectMarkSeen(mock, "dp1", now.Unix(), true)
+		mock.ExpectQuery(`SELECT "status","last_seen_at" FROM "delivery_people" WHERE delivery_person_id = \$1`).
+			WithArgs("dp1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"status", "last_seen_at"}).AddRow("AVAILABLE", now.Unix()))
+		mock.ExpectCommit()
+
+		resp, err := service.Heartbeat(context.Background(), &pb.HeartbeatRequest{DeliveryPersonId: "dp1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "AVAILABLE", resp.Status)
+		assert.Equal(t, now.Unix(), resp.LastSeenAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Delivery Person", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "delivery_people" SET "last_seen_at"=\$1`).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		resp, err := service.Heartbeat(context.Background(), &pb.HeartbeatRequest{DeliveryPersonId: "ghost"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestSweepSilentDrivers(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE status IN \(\$2,\$3,\$4\) AND last_seen_at < \$5`).
+		WithArgs("OFFLINE", "AVAILABLE", "BUSY", "STALE", now.Add(-10*time.Minute).Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE status IN \(\$2,\$3\) AND last_seen_at < \$4`).
+		WithArgs("STALE", "AVAILABLE", "BUSY", now.Add(-2*time.Minute).Unix()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE "orders" SET "attention_reason"=\$1,"needs_attention"=\$2,"updated_at"=\$3 WHERE status IN \(\$4,\$5,\$6\) AND needs_attention = \$7 AND delivery_person_id IN \(SELECT "delivery_person_id" FROM "delivery_people" WHERE status IN \(\$8,\$9\)\)`).
+		WithArgs("DRIVER_UNRESPONSIVE", true, sqlmock.AnyArg(), "ASSIGNED", "IN_PROGRESS", "RETURNING", false, "STALE", "OFFLINE").
+		WillReturnResult(sqlmock.NewResult(0, 4))
+	mock.ExpectQuery(`SELECT \* FROM "offers" WHERE status = \$1 AND delivery_person_id IN \(SELECT "delivery_person_id" FROM "delivery_people" WHERE status IN \(\$2,\$3\)\)`).
+		WithArgs("PENDING", "STALE", "OFFLINE").
+		WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer1", "order1", "dp1", "PENDING", now.Unix()+10))
+	mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+		WithArgs("driver offline", now.Unix(), "EXPIRED", "offer1", "PENDING").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOrderLookup(mock, "order1")
+	expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	changed, err := service.SweepSilentDrivers(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, changed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
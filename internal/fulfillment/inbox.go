@@ -0,0 +1,187 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPoisonMessage marks an inbound event that can never be processed, no
+// matter how often it is retried. Consumers set such events aside instead of
+// retrying them.
+var ErrPoisonMessage = errors.New("poison message")
+
+// errDuplicateEvent reports an order-created event that was already handled.
+var errDuplicateEvent = errors.New("duplicate event")
+
+// HandleOrderCreated creates the order described by an order-created event
+// and dispatches it, as AssignOrder would. Events are delivered at least
+// once, so the event is recorded as processed in the same transaction that
+// creates the order, and an event already recorded, or for an order that
+// already exists, is ignored. Nobody being free to take the order is no fault
+// of the event: the order is kept as UNASSIGNED and offered again later.
+func (s *OrderService) HandleOrderCreated(ctx context.Context, event *pb.Event) error {
+	created := event.GetOrderCreated()
+	if created == nil {
+		return fmt.Errorf("%w: event %s is not an order-created event", ErrPoisonMessage, event.EventId)
+	}
+	if event.EventId == "" {
+		return fmt.Errorf("%w: order-created event for %s has no event ID", ErrPoisonMessage, created.OrderId)
+	}
+	if created.OrderId == "" {
+		return fmt.Errorf("%w: event %s has no order ID", ErrPoisonMessage, event.EventId)
+	}
+	// Unset coordinates arrive as zero; an order is never picked up or
+	// dropped off at 0,0.
+	if created.PickupLat == 0 && created.PickupLng == 0 {
+		return fmt.Errorf("%w: event %s has no pickup location", ErrPoisonMessage, event.EventId)
+	}
+	if created.DropoffLat == 0 && created.DropoffLng == 0 {
+		return fmt.Errorf("%w: event %s has no dropoff location", ErrPoisonMessage, event.EventId)
+	}
+
+	claim := func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&ProcessedEvent{EventID: event.EventId, ProcessedAt: s.now().Unix()})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDuplicateEvent
+		}
+		var existing int64
+		if err := tx.Model(&Order{}).Where("order_id = ?", created.OrderId).Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errDuplicateEvent
+		}
+		return nil
+	}
+	_, err := s.assignOrder(ctx, &pb.AssignOrderRequest{
+		OrderId:             created.OrderId,
+		Pickup:              &pb.Location{Lat: created.PickupLat, Lng: created.PickupLng},
+		Dropoff:             &pb.Location{Lat: created.DropoffLat, Lng: created.DropoffLng},
+		WeightKg:            created.WeightKg,
+		VolumeLiters:        created.VolumeLiters,
+		PackageSize:         created.PackageSize,
+		Priority:            created.Priority,
+		DeliveryWindowStart: created.DeliveryWindowStart,
+		DeliveryWindowEnd:   created.DeliveryWindowEnd,
+	}, assignOptions{claim: claim, keepUnassigned: true})
+	if errors.Is(err, errDuplicateEvent) {
+		return nil
+	}
+	if status.Code(err) == codes.InvalidArgument {
+		return fmt.Errorf("%w: %v", ErrPoisonMessage, err)
+	}
+	return err
+}
+
+// ConsumeOrderInbox processes order-created events that upstream services
+// have queued in the inbox_events table, oldest first. A failed event is
+// retried with exponential backoff; a poison event, or one that still fails
+// after InboxMaxAttempts, is marked dead and left for ops. It returns the
+// number of events processed.
+func (s *OrderService) ConsumeOrderInbox(ctx context.Context) (int, error) {
+	processed := 0
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var rows []InboxEvent
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("processed_at = 0 AND dead_at = 0 AND next_attempt_at <= ?", s.now().Unix()).
+			Order("id").
+			Limit(s.cfg.InboxBatchSize).
+			Find(&rows).Error; err != nil {
+			return err
+		}
+
+		for i := range rows {
+			row := &rows[i]
+			// The order and its event claim commit with the inbox row.
+			err := s.handleInboxEvent(withTransaction(ctx, tx), row)
+			if err == nil {
+				if err := tx.Model(row).Update("processed_at", s.now().Unix()).Error; err != nil {
+					return err
+				}
+				processed++
+				continue
+			}
+			if err := s.retryOrBuryInboxEvent(tx, row, err); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return processed, err
+}
+
+func (s *OrderService) handleInboxEvent(ctx context.Context, row *InboxEvent) error {
+	event := &pb.Event{}
+	if err := protojson.Unmarshal([]byte(row.Payload), event); err != nil {
+		return fmt.Errorf("%w: %v", ErrPoisonMessage, err)
+	}
+	return s.HandleOrderCreated(ctx, event)
+}
+
+// retryOrBuryInboxEvent records a failed attempt, scheduling a retry with
+// exponential backoff or marking the event dead.
+func (s *OrderService) retryOrBuryInboxEvent(tx *gorm.DB, row *InboxEvent, cause error) error {
+	row.Attempts++
+	updates := map[string]interface{}{
+		"attempts":   row.Attempts,
+		"last_error": cause.Error(),
+	}
+	if errors.Is(cause, ErrPoisonMessage) || row.Attempts >= s.cfg.InboxMaxAttempts {
+		logging.FromContext(tx.Statement.Context).Warn("giving up on inbox event",
+			"inbox_event_id", row.ID, "attempts", row.Attempts, "error", cause)
+		updates["dead_at"] = s.now().Unix()
+	} else {
+		updates["next_attempt_at"] = s.now().Add(backoff(s.cfg.InboxRetryBackoff, s.cfg.InboxMaxBackoff, row.Attempts)).Unix()
+	}
+	return tx.Model(row).Updates(updates).Error
+}
+
+// QueueOrderEvent puts an order-created event that failed when it arrived by
+// other means into the inbox, where ConsumeOrderInbox retries it like events
+// queued by upstream services. The failure counts as its first attempt.
+func (s *OrderService) QueueOrderEvent(ctx context.Context, event *pb.Event, cause error) error {
+	payload, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return s.db.WithContext(ctx).Create(&InboxEvent{
+		Payload:       string(payload),
+		Attempts:      1,
+		NextAttemptAt: s.now().Add(backoff(s.cfg.InboxRetryBackoff, s.cfg.InboxMaxBackoff, 1)).Unix(),
+		LastError:     cause.Error(),
+		CreatedAt:     s.now().Unix(),
+	}).Error
+}
+
+// RunOrderInbox calls ConsumeOrderInbox every interval until ctx is
+// cancelled.
+func (s *OrderService) RunOrderInbox(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ConsumeOrderInbox(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to consume order inbox", "error", err)
+			}
+		}
+	}
+}
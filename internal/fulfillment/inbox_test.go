@@ -0,0 +1,187 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectEventClaim expects an order-created event to be recorded as
+// processed, affecting no rows if it already was.
+func expectEventClaim(mock sqlmock.Sqlmock, eventID string, rowsAffected int64) {
+	mock.ExpectExec(`INSERT INTO "processed_events" \("event_id","processed_at"\) VALUES \(\$1,\$2\) ON CONFLICT DO NOTHING`).
+		WithArgs(eventID, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+}
+
+func TestHandleOrderCreated(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	created := func(orderID, packageSize string) *pb.Event {
+		return &pb.Event{EventId: "e1", Payload: &pb.Event_OrderCreated{OrderCreated: &pb.OrderCreated{
+			OrderId:     orderID,
+			PackageSize: packageSize,
+			PickupLat:   40.7128,
+			PickupLng:   -74.0060,
+			DropoffLat:  40.7306,
+			DropoffLng:  -73.9352,
+		}}}
+	}
+	expectExisting := func(orderID string, count int) {
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "orders" WHERE order_id = \$1`).
+			WithArgs(orderID).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+	}
+
+	t.Run("Success - Duplicate Event Is Ignored", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectEventClaim(mock, "e1", 0)
+		mock.ExpectRollback()
+
+		err := service.HandleOrderCreated(context.Background(), created("order1", "SMALL"))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Existing Order Is Ignored", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectEventClaim(mock, "e1", 1)
+		expectExisting("order1", 1)
+		mock.ExpectRollback()
+
+		err := service.HandleOrderCreated(context.Background(), created("order1", "SMALL"))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Order Nobody Can Take Is Kept Unassigned", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectEventClaim(mock, "e1", 1)
+		expectExisting("order3", 0)
+		expectZoneLookup(mock, "")
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT orders\.\* FROM "orders" JOIN delivery_people`).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+		expectNoCandidates(mock, "order3")
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("UNASSIGNED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order3").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order3")
+		mock.ExpectCommit()
+
+		err := service.HandleOrderCreated(context.Background(), created("order3", "SMALL"))
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Invalid Order Is Poison", func(t *testing.T) {
+		err := service.HandleOrderCreated(context.Background(), created("order2", "PALLET"))
+
+		assert.ErrorIs(t, err, ErrPoisonMessage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Missing Location Is Poison", func(t *testing.T) {
+		event := created("order2", "SMALL")
+		event.GetOrderCreated().PickupLat, event.GetOrderCreated().PickupLng = 0, 0
+		assert.ErrorIs(t, service.HandleOrderCreated(context.Background(), event), ErrPoisonMessage)
+
+		event = created("order2", "SMALL")
+		event.GetOrderCreated().DropoffLat, event.GetOrderCreated().DropoffLng = 0, 0
+		assert.ErrorIs(t, service.HandleOrderCreated(context.Background(), event), ErrPoisonMessage)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Wrong Event Is Poison", func(t *testing.T) {
+		event := &pb.Event{EventId: "e2", Payload: &pb.Event_OrderAssigned{OrderAssigned: &pb.OrderAssigned{OrderId: "order1"}}}
+		unidentified := created("order1", "SMALL")
+		unidentified.EventId = ""
+
+		assert.ErrorIs(t, service.HandleOrderCreated(context.Background(), event), ErrPoisonMessage)
+		assert.ErrorIs(t, service.HandleOrderCreated(context.Background(), created("", "SMALL")), ErrPoisonMessage)
+		assert.ErrorIs(t, service.HandleOrderCreated(context.Background(), unidentified), ErrPoisonMessage)
+	})
+}
+
+func TestQueueOrderEvent(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO "inbox_events" \("payload","attempts","next_attempt_at","last_error","created_at","processed_at","dead_at"\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6,\$7\) RETURNING "id"`).
+		WithArgs(sqlmock.AnyArg(), 1, now.Add(time.Second).Unix(), "connection reset", now.Unix(), 0, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	event := &pb.Event{EventId: "e1", Payload: &pb.Event_OrderCreated{OrderCreated: &pb.OrderCreated{OrderId: "order1"}}}
+	err := service.QueueOrderEvent(context.Background(), event, errors.New("connection reset"))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestConsumeOrderInbox(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "inbox_events" WHERE processed_at = 0 AND dead_at = 0 AND next_attempt_at <= \$1 ORDER BY id LIMIT \$2 FOR UPDATE SKIP LOCKED`).
+		WithArgs(now.Unix(), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "payload", "attempts"}).
+			AddRow(1, `{"eventId":"e1","orderCreated":{"orderId":"order1","packageSize":"SMALL","pickupLat":40.7,"pickupLng":-74,"dropoffLat":40.8,"dropoffLng":-73.9}}`, 0).
+			AddRow(2, `not json`, 0).
+			AddRow(3, `{"eventId":"e3","orderCreated":{"orderId":"order3","packageSize":"SMALL","pickupLat":40.7,"pickupLng":-74,"dropoffLat":40.8,"dropoffLng":-73.9}}`, 1))
+
+	// Each event is handled in a savepoint of the inbox transaction.
+	mock.ExpectExec(`SAVEPOINT sp`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectEventClaim(mock, "e1", 1)
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "orders"`).
+		WithArgs("order1").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "inbox_events" SET "processed_at"=\$1 WHERE "id" = \$2`).
+		WithArgs(now.Unix(), 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(`UPDATE "inbox_events" SET "attempts"=\$1,"dead_at"=\$2,"last_error"=\$3 WHERE "id" = \$4`).
+		WithArgs(1, now.Unix(), sqlmock.AnyArg(), 2).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	mock.ExpectExec(`SAVEPOINT sp`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "processed_events"`).
+		WithArgs("e3", sqlmock.AnyArg()).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT sp`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "inbox_events" SET "attempts"=\$1,"last_error"=\$2,"next_attempt_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(2, "connection reset", now.Add(2*time.Second).Unix(), 3).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	processed, err := service.ConsumeOrderInbox(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, processed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
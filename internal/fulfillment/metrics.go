@@ -0,0 +1,136 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"fullfillment-service/internal/logging"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+var (
+	assignmentLatency = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "fulfillment",
+		Name:      "assignment_latency_seconds",
+		Help:      "Time from an order being ready for dispatch to a delivery person accepting it.",
+		Buckets:   []float64{5, 15, 30, 60, 120, 300, 600, 1200, 1800, 3600},
+	}, []string{"priority"})
+
+	assignmentFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "fulfillment",
+		Name:      "assignment_failures_total",
+		Help:      "Orders that could not be assigned, by reason.",
+	}, []string{"reason"})
+
+	timeInStatus = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "fulfillment",
+		Name:      "order_time_in_status_seconds",
+		Help:      "How long orders stayed in a status before moving on.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 16),
+	}, []string{"status"})
+
+	availableDriversDesc = prometheus.NewDesc(
+		"fulfillment_available_delivery_people",
+		"Delivery people available for new orders, by zone.",
+		[]string{"zone"}, nil,
+	)
+	ordersDesc = prometheus.NewDesc(
+		"fulfillment_orders",
+		"Orders by status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// assignmentFailureReason labels an error returned by AssignOrder.
+func assignmentFailureReason(err error) string {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return "invalid_order"
+	case codes.ResourceExhausted:
+		return "no_delivery_person"
+	default:
+		return "error"
+	}
+}
+
+// observeStatusChange records, once tx commits, how long order spent in its
+// status before moving to status and, for an assignment, how long it waited
+// to be accepted. order is the order as it was before the change.
+func (s *OrderService) observeStatusChange(tx *gorm.DB, order *Order, status string) {
+	now := s.now().Unix()
+	since := order.StatusChangedAt
+	if since == 0 {
+		since = order.CreatedAt
+	}
+	from, priority := order.Status, order.Priority
+	ready := max(order.CreatedAt, order.DispatchAt)
+
+	afterCommit(tx, func() {
+		if since != 0 {
+			timeInStatus.WithLabelValues(from).Observe(float64(now - since))
+		}
+		switch status {
+		case OrderStatusAssigned:
+			if ready != 0 {
+				assignmentLatency.WithLabelValues(priority).Observe(float64(now - ready))
+			}
+		case OrderStatusUnassigned:
+			assignmentFailures.WithLabelValues("unassigned").Inc()
+		}
+	})
+}
+
+// Collector reports gauges that are read from the database when metrics are
+// scraped: available delivery people per zone and orders per status.
+func (s *OrderService) Collector() prometheus.Collector {
+	return &statsCollector{service: s}
+}
+
+type statsCollector struct {
+	service *OrderService
+}
+
+func (c *statsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- availableDriversDesc
+	ch <- ordersDesc
+}
+
+func (c *statsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	db := c.service.db.WithContext(ctx)
+
+	var drivers []struct {
+		ZoneID string
+		Count  int64
+	}
+	if err := db.Model(&DeliveryPerson{}).
+		Select("zone_id, count(*) AS count").
+		Where("status = ?", DeliveryPersonAvailable).
+		Group("zone_id").
+		Scan(&drivers).Error; err != nil {
+		logging.FromContext(ctx).Error("failed to collect delivery people metrics", "error", err)
+	}
+	for _, row := range drivers {
+		ch <- prometheus.MustNewConstMetric(availableDriversDesc, prometheus.GaugeValue, float64(row.Count), row.ZoneID)
+	}
+
+	var orders []struct {
+		Status string
+		Count  int64
+	}
+	if err := db.Model(&Order{}).
+		Select("status, count(*) AS count").
+		Group("status").
+		Scan(&orders).Error; err != nil {
+		logging.FromContext(ctx).Error("failed to collect order metrics", "error", err)
+	}
+	for _, row := range orders {
+		ch <- prometheus.MustNewConstMetric(ordersDesc, prometheus.GaugeValue, float64(row.Count), row.Status)
+	}
+}
@@ -0,0 +1,111 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func TestObserveStatusChange(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+	order := &Order{
+		OrderID:         "order1",
+		Status:          OrderStatusOffered,
+		Priority:        PriorityExpress,
+		CreatedAt:       now.Unix() - 600,
+		StatusChangedAt: now.Unix() - 90,
+	}
+
+	t.Run("Observed After Commit", func(t *testing.T) {
+		offered := histogram(t, timeInStatus, OrderStatusOffered)
+		express := histogram(t, assignmentLatency, PriorityExpress)
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		err := service.transaction(context.Background(), func(tx *gorm.DB) error {
+			service.observeStatusChange(tx, order, OrderStatusAssigned)
+			assert.Equal(t, offered.GetSampleCount(), histogram(t, timeInStatus, OrderStatusOffered).GetSampleCount())
+			return nil
+		})
+
+		assert.NoError(t, err)
+		after := histogram(t, timeInStatus, OrderStatusOffered)
+		assert.Equal(t, offered.GetSampleCount()+1, after.GetSampleCount())
+		assert.Equal(t, offered.GetSampleSum()+90, after.GetSampleSum())
+		after = histogram(t, assignmentLatency, PriorityExpress)
+		assert.Equal(t, express.GetSampleCount()+1, after.GetSampleCount())
+		assert.Equal(t, express.GetSampleSum()+600, after.GetSampleSum())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Not Observed After Rollback", func(t *testing.T) {
+		offered := histogram(t, timeInStatus, OrderStatusOffered)
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		err := service.transaction(context.Background(), func(tx *gorm.DB) error {
+			service.observeStatusChange(tx, order, OrderStatusAssigned)
+			return errors.New("conflict")
+		})
+
+		assert.Error(t, err)
+		assert.Equal(t, offered.GetSampleCount(), histogram(t, timeInStatus, OrderStatusOffered).GetSampleCount())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func histogram(t *testing.T, vec *prometheus.HistogramVec, label string) *dto.Histogram {
+	t.Helper()
+	var metric dto.Metric
+	require.NoError(t, vec.WithLabelValues(label).(prometheus.Histogram).Write(&metric))
+	return metric.GetHistogram()
+}
+
+func TestAssignmentFailureReason(t *testing.T) {
+	assert.Equal(t, "invalid_order", assignmentFailureReason(status.Error(codes.InvalidArgument, "bad")))
+	assert.Equal(t, "no_delivery_person", assignmentFailureReason(errNoDeliveryPerson))
+	assert.Equal(t, "error", assignmentFailureReason(assert.AnError))
+}
+
+func TestCollector(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectQuery(`SELECT zone_id, count\(\*\) AS count FROM "delivery_people" WHERE status = \$1 GROUP BY "zone_id"`).
+		WithArgs("AVAILABLE").
+		WillReturnRows(sqlmock.NewRows([]string{"zone_id", "count"}).AddRow("midtown", 3).AddRow("brooklyn", 1))
+	mock.ExpectQuery(`SELECT status, count\(\*\) AS count FROM "orders" GROUP BY "status"`).
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).AddRow("OFFERED", 2).AddRow("DELIVERED", 40))
+
+	err := testutil.CollectAndCompare(service.Collector(), strings.NewReader(`
+# HELP fulfillment_available_delivery_people Delivery people available for new orders, by zone.
+# TYPE fulfillment_available_delivery_people gauge
+fulfillment_available_delivery_people{zone="brooklyn"} 1
+fulfillment_available_delivery_people{zone="midtown"} 3
+# HELP fulfillment_orders Orders by status.
+# TYPE fulfillment_orders gauge
+fulfillment_orders{status="DELIVERED"} 40
+fulfillment_orders{status="OFFERED"} 2
+`))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
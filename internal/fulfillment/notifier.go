@@ -0,0 +1,41 @@
+package fulfillment
+
+import (
+	"context"
+
+	"fullfillment-service/internal/logging"
+)
+
+// Alert is raised when an order's SLA status gets worse.
+type Alert struct {
+	OrderID     string
+	ZoneID      string
+	Priority    string
+	SLAStatus   string
+	DueAt       int64
+	DeliveryETA int64
+	RaisedAt    int64
+}
+
+// Notifier delivers alerts to whoever has to act on them.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// NotifierFunc adapts an ordinary function to a Notifier.
+type NotifierFunc func(ctx context.Context, alert Alert) error
+
+func (f NotifierFunc) Notify(ctx context.Context, alert Alert) error {
+	return f(ctx, alert)
+}
+
+// LogNotifier writes alerts to the logger in the context. It is used unless
+// another notifier is configured.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, alert Alert) error {
+	logging.FromContext(ctx).Warn("SLA "+alert.SLAStatus,
+		"order_id", alert.OrderID, "zone_id", alert.ZoneID, "priority", alert.Priority,
+		"due_at", alert.DueAt, "delivery_eta", alert.DeliveryETA)
+	return nil
+}
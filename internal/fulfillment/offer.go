@@ -0,0 +1,388 @@
+package fulfillment
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errNoDeliveryPerson = status.Error(codes.ResourceExhausted, "no available delivery person within the dispatch radius")
+
+// Reasons recorded on offers that were open when they could no longer be
+// accepted.
+const (
+	orderWithdrawnExpireReason = "order no longer on offer"
+	cannotTakeDeclineReason    = "delivery person cannot take the order"
+)
+
+// OfferStats summarises how a delivery person has answered the offers made to them.
+type OfferStats struct {
+	Accepted int64
+	Declined int64
+	Expired  int64
+}
+
+// AcceptanceRate is the share of answered or expired offers that were accepted.
+func (o OfferStats) AcceptanceRate() float64 {
+	total := o.Accepted + o.Declined + o.Expired
+	if total == 0 {
+		return 0
+	}
+	return float64(o.Accepted) / float64(total)
+}
+
+func (s *OrderService) AcceptOffer(ctx context.Context, req *pb.AcceptOfferRequest) (*pb.AcceptOfferResponse, error) {
+	var offer Offer
+	var rejected error
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		if err := s.loadPendingOffer(tx, req.OfferId, req.DeliveryPersonId, &offer); err != nil {
+			return err
+		}
+
+		if s.now().Unix() >= offer.ExpiresAt {
+			rejected = status.Error(codes.FailedPrecondition, "offer has expired")
+			return s.closeOffer(tx, &offer, OfferExpired, "")
+		}
+
+		var order Order
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND status = ?", offer.OrderID, OrderStatusOffered).
+			First(&order).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			rejected = status.Error(codes.FailedPrecondition, "order is no longer on offer")
+			return s.closeOffer(tx, &offer, OfferExpired, orderWithdrawnExpireReason)
+		}
+		if err != nil {
+			return err
+		}
+
+		// The driver may have gone off shift, gone silent or filled up since
+		// the offer was made; pass the order on rather than overload them.
+		var fits int64
+		if err := tx.Model(&DeliveryPerson{}).
+			Where("delivery_person_id = ? AND status = ?", offer.DeliveryPersonID, DeliveryPersonAvailable).
+			Scopes(onShift, withSuitableVehicle(&order), withSpareCapacity(&order)).
+			Count(&fits).Error; err != nil {
+			return err
+		}
+		if fits == 0 {
+			rejected = status.Error(codes.FailedPrecondition, "delivery person cannot take the order")
+			return s.closeOffer(tx, &offer, OfferDeclined, cannotTakeDeclineReason)
+		}
+
+		if err := tx.Model(&offer).Updates(map[string]interface{}{
+			"status":       OfferAccepted,
+			"responded_at": s.now().Unix(),
+		}).Error; err != nil {
+			return err
+		}
+		previous := order
+		if err := tx.Model(&order).Updates(map[string]interface{}{
+			"delivery_person_id": offer.DeliveryPersonID,
+			"status":             OrderStatusAssigned,
+			"status_changed_at":  s.now().Unix(),
+		}).Error; err != nil {
+			return err
+		}
+		s.observeStatusChange(tx, &previous, OrderStatusAssigned)
+		if err := s.recordOrderEvent(tx, EventOrderAssigned, offer.OrderID, offer.DeliveryPersonID, OrderStatusAssigned); err != nil {
+			return err
+		}
+		if err := issueDeliveryPin(tx, offer.OrderID); err != nil {
+			return err
+		}
+		if offer.BatchID != "" {
+			if err := tx.Model(&Order{}).
+				Where("order_id IN ?", []string{offer.BatchID, offer.OrderID}).
+				Update("batch_id", offer.BatchID).Error; err != nil {
+				return err
+			}
+		}
+		if err := refreshDriverStatus(tx, offer.DeliveryPersonID); err != nil {
+			return err
+		}
+		return s.planRoute(tx, offer.DeliveryPersonID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+
+	return &pb.AcceptOfferResponse{Status: OfferAccepted, OrderId: offer.OrderID}, nil
+}
+
+func (s *OrderService) DeclineOffer(ctx context.Context, req *pb.DeclineOfferRequest) (*pb.DeclineOfferResponse, error) {
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var offer Offer
+		if err := s.loadPendingOffer(tx, req.OfferId, req.DeliveryPersonId, &offer); err != nil {
+			return err
+		}
+		return s.closeOffer(tx, &offer, OfferDeclined, req.Reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.DeclineOfferResponse{Status: OfferDeclined}, nil
+}
+
+// ExpireOffers closes every pending offer whose deadline has passed and hands
+// the affected orders to their next-best candidate. It returns the number of
+// offers that were expired.
+func (s *OrderService) ExpireOffers(ctx context.Context) (int, error) {
+	var offers []Offer
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND expires_at <= ?", OfferPending, s.now().Unix()).
+		Find(&offers).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range offers {
+		offer := offers[i]
+		err := s.transaction(ctx, func(tx *gorm.DB) error {
+			return s.closeOffer(tx, &offer, OfferExpired, "")
+		})
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// RunOfferExpiry calls ExpireOffers every interval until ctx is cancelled.
+func (s *OrderService) RunOfferExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.ExpireOffers(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to expire offers", "error", err)
+			}
+		}
+	}
+}
+
+// RedispatchUnassigned offers UNASSIGNED orders again, most urgent first, to
+// delivery people who have come free or on shift since nobody could be found
+// for them. Orders that still find nobody are left as they are. It returns
+// the number of orders offered.
+func (s *OrderService) RedispatchUnassigned(ctx context.Context) (int, error) {
+	var orders []Order
+	if err := s.db.WithContext(ctx).
+		Where("status = ?", OrderStatusUnassigned).
+		Order(priorityOrder).
+		Order("created_at").
+		Find(&orders).Error; err != nil {
+		return 0, err
+	}
+
+	offered := 0
+	for i := range orders {
+		err := s.transaction(ctx, func(tx *gorm.DB) error {
+			var order Order
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("order_id = ? AND status = ?", orders[i].OrderID, OrderStatusUnassigned).
+				First(&order).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// Redispatched by another instance meanwhile.
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			offer, err := s.offerNext(tx, &order)
+			if err != nil || offer == nil {
+				return err
+			}
+			offered++
+			return s.setOrderStatus(tx, &order, OrderStatusOffered)
+		})
+		if err != nil {
+			return offered, err
+		}
+	}
+	return offered, nil
+}
+
+// RunRedispatcher calls RedispatchUnassigned every interval until ctx is
+// cancelled.
+func (s *OrderService) RunRedispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RedispatchUnassigned(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to redispatch unassigned orders", "error", err)
+			}
+		}
+	}
+}
+
+func (s *OrderService) OfferStats(ctx context.Context, deliveryPersonID string) (OfferStats, error) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	if err := s.db.WithContext(ctx).Model(&Offer{}).
+		Select("status, COUNT(*) AS count").
+		Where("delivery_person_id = ?", deliveryPersonID).
+		Group("status").
+		Scan(&rows).Error; err != nil {
+		return OfferStats{}, err
+	}
+
+	var stats OfferStats
+	for _, row := range rows {
+		switch row.Status {
+		case OfferAccepted:
+			stats.Accepted = row.Count
+		case OfferDeclined:
+			stats.Declined = row.Count
+		case OfferExpired:
+			stats.Expired = row.Count
+		}
+	}
+	return stats, nil
+}
+
+func (s *OrderService) loadPendingOffer(tx *gorm.DB, offerID, deliveryPersonID string, offer *Offer) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(offer, "offer_id = ?", offerID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return status.Error(codes.NotFound, "offer not found")
+	}
+	if err != nil {
+		return err
+	}
+	if offer.DeliveryPersonID != deliveryPersonID {
+		return status.Error(codes.PermissionDenied, "offer belongs to another delivery person")
+	}
+	if offer.Status != OfferPending {
+		return status.Errorf(codes.FailedPrecondition, "offer is already %s", offer.Status)
+	}
+	return nil
+}
+
+// closeOffer records the outcome of an offer that was not accepted and
+// passes its order on to the next-best candidate.
+func (s *OrderService) closeOffer(tx *gorm.DB, offer *Offer, outcome, reason string) error {
+	result := tx.Model(&Offer{}).
+		Where("offer_id = ? AND status = ?", offer.OfferID, OfferPending).
+		Updates(map[string]interface{}{
+			"status":         outcome,
+			"decline_reason": reason,
+			"responded_at":   s.now().Unix(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Someone else answered the offer first.
+		return nil
+	}
+
+	var order Order
+	if err := tx.First(&order, "order_id = ?", offer.OrderID).Error; err != nil {
+		return err
+	}
+	if order.Status != OrderStatusOffered {
+		// Handed out some other way meanwhile; there is nothing to pass on.
+		return nil
+	}
+	next, err := s.offerNext(tx, &order)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		return s.setOrderStatus(tx, &order, OrderStatusUnassigned)
+	}
+	return nil
+}
+
+// defaultPickup is where candidates are measured from for orders placed
+// without pickup coordinates.
+var defaultPickup = Point{Lat: 40.748817, Lng: -73.985428}
+
+// offerNext offers the order to the best delivery person who has room for it
+// and has neither seen this order before nor has another offer outstanding:
+// someone who can batch it with an order from the same pickup if possible,
+// otherwise whoever is nearest, and for urgent orders failing that someone
+// whose less urgent order can be preempted. It returns nil when nobody is
+// left to ask.
+func (s *OrderService) offerNext(tx *gorm.DB, order *Order) (*Offer, error) {
+	asked := tx.Model(&Offer{}).
+		Select("delivery_person_id").
+		Where("order_id = ? OR status = ?", order.OrderID, OfferPending)
+
+	deliveryPersonID, batchID, err := s.findBatch(tx, order, asked)
+	if err != nil {
+		return nil, err
+	}
+
+	if deliveryPersonID == "" {
+		pickup := defaultPickup
+		if order.Pickup != nil {
+			pickup = *order.Pickup
+		}
+
+		deliveryPersonID, err = s.nearestCandidate(tx, order, pickup, asked)
+		if err != nil {
+			return nil, err
+		}
+		if deliveryPersonID == "" {
+			deliveryPersonID, err = s.preemptCandidate(tx, order, pickup, asked)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if deliveryPersonID == "" {
+			return nil, nil
+		}
+	}
+
+	offer := &Offer{
+		OfferID:          newID(),
+		OrderID:          order.OrderID,
+		DeliveryPersonID: deliveryPersonID,
+		Status:           OfferPending,
+		BatchID:          batchID,
+		ExpiresAt:        s.now().Add(s.cfg.OfferTimeout).Unix(),
+	}
+	if err := tx.Create(offer).Error; err != nil {
+		return nil, err
+	}
+	if err := s.estimateOffer(tx, order, deliveryPersonID); err != nil {
+		return nil, err
+	}
+	return offer, nil
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,317 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var offerColumns = []string{"offer_id", "order_id", "delivery_person_id", "status", "expires_at"}
+
+func expectOrderLookup(mock sqlmock.Sqlmock, orderID string) {
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+		WithArgs(orderID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow(orderID, "OFFERED"))
+}
+
+// expectOfferedOrderLock expects AcceptOffer to lock the order while it is
+// still on offer, finding it or not.
+func expectOfferedOrderLock(mock sqlmock.Sqlmock, orderID string, found bool) {
+	rows := sqlmock.NewRows([]string{"order_id", "status"})
+	if found {
+		rows.AddRow(orderID, "OFFERED")
+	}
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 AND status = \$2 ORDER BY .* FOR UPDATE`).
+		WithArgs(orderID, "OFFERED", 1).
+		WillReturnRows(rows)
+}
+
+// expectDriverFits expects AcceptOffer to check that the delivery person is
+// still free, on shift and has room for the order.
+func expectDriverFits(mock sqlmock.Sqlmock, deliveryPersonID string, fits bool) {
+	count := 0
+	if fits {
+		count = 1
+	}
+	mock.ExpectQuery(`SELECT count\(\*\) FROM "delivery_people" WHERE \(delivery_person_id = \$1 AND status = \$2\) AND \(EXISTS \(SELECT 1 FROM shifts .*\)\) AND delivery_people\.vehicle_type IN .* AND \(max_weight_kg = 0 .*\) AND \(max_volume_liters = 0 .*\)`).
+		WithArgs(deliveryPersonID, "AVAILABLE", "BIKE", "SCOOTER", "CAR", "VAN",
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg(),
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(count))
+}
+
+func TestAcceptOffer(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Accept Pending Offer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1 .* FOR UPDATE`).
+			WithArgs("offer1", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer1", "order1", "dp1", "PENDING", now.Unix()+10))
+		expectOfferedOrderLock(mock, "order1", true)
+		expectDriverFits(mock, "dp1", true)
+		mock.ExpectExec(`UPDATE "offers" SET "responded_at"=\$1,"status"=\$2 WHERE "offer_id" = \$3`).
+			WithArgs(now.Unix(), "ACCEPTED", "offer1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "orders" SET "delivery_person_id"=\$1,"status"=\$2,"status_changed_at"=\$3,"updated_at"=\$4 WHERE "order_id" = \$5`).
+			WithArgs("dp1", "ASSIGNED", now.Unix(), sqlmock.AnyArg(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.assigned", "order1")
+		expectPinIssued(mock, "order1")
+		expectDriverStatusRefresh(mock, "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+		mock.ExpectCommit()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer1", DeliveryPersonId: "dp1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "ACCEPTED", resp.Status)
+		assert.Equal(t, "order1", resp.OrderId)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Offer Expired", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer2", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer2", "order2", "dp1", "PENDING", now.Unix()-1))
+		mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+			WithArgs("", now.Unix(), "EXPIRED", "offer2", "PENDING").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOrderLookup(mock, "order2")
+		expectNoCandidates(mock, "order2")
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("UNASSIGNED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order2").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order2")
+		mock.ExpectCommit()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer2", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Order No Longer On Offer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer5", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer5", "order5", "dp1", "PENDING", now.Unix()+10))
+		expectOfferedOrderLock(mock, "order5", false)
+		mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+			WithArgs("order no longer on offer", now.Unix(), "EXPIRED", "offer5", "PENDING").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+			WithArgs("order5", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow("order5", "ASSIGNED"))
+		mock.ExpectCommit()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer5", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "no longer on offer")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Delivery Person Cannot Take The Order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer6", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer6", "order6", "dp1", "PENDING", now.Unix()+10))
+		expectOfferedOrderLock(mock, "order6", true)
+		expectDriverFits(mock, "dp1", false)
+		mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+			WithArgs("delivery person cannot take the order", now.Unix(), "DECLINED", "offer6", "PENDING").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOrderLookup(mock, "order6")
+		expectCandidateQuery(mock, "order6", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order6", "dp2", "PENDING", "", "", now.Unix()+30)...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer6", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "cannot take the order")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Offer Belongs To Someone Else", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer3", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer3", "order3", "dp2", "PENDING", now.Unix()+10))
+		mock.ExpectRollback()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer3", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Offer Already Answered", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer4", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer4", "order4", "dp1", "DECLINED", now.Unix()+10))
+		mock.ExpectRollback()
+
+		resp, err := service.AcceptOffer(context.Background(), &pb.AcceptOfferRequest{OfferId: "offer4", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeclineOffer(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Decline Offers Order To Next Candidate", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("offer1", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer1", "order1", "dp1", "PENDING", now.Unix()+10))
+		mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+			WithArgs("too far", now.Unix(), "DECLINED", "offer1", "PENDING").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOrderLookup(mock, "order1")
+		expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order1", "dp2", "PENDING", "", "", now.Unix()+30)...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.DeclineOffer(context.Background(), &pb.DeclineOfferRequest{OfferId: "offer1", DeliveryPersonId: "dp1", Reason: "too far"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "DECLINED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Offer Not Found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE offer_id = \$1`).
+			WithArgs("missing", 1).
+			WillReturnRows(sqlmock.NewRows(offerColumns))
+		mock.ExpectRollback()
+
+		resp, err := service.DeclineOffer(context.Background(), &pb.DeclineOfferRequest{OfferId: "missing", DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestExpireOffers(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectQuery(`SELECT \* FROM "offers" WHERE status = \$1 AND expires_at <= \$2`).
+		WithArgs("PENDING", now.Unix()).
+		WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer1", "order1", "dp1", "PENDING", now.Unix()-5))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+		WithArgs("", now.Unix(), "EXPIRED", "offer1", "PENDING").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOrderLookup(mock, "order1")
+	expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order1", "dp2", "PENDING", "", "", now.Unix()+30)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	expired, err := service.ExpireOffers(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRedispatchUnassigned(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	expectUnassignedLock := func(orderID string) {
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 AND status = \$2 ORDER BY .* FOR UPDATE`).
+			WithArgs(orderID, "UNASSIGNED", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow(orderID, "UNASSIGNED"))
+	}
+
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE status = \$1 ORDER BY CASE priority .* END DESC,created_at`).
+		WithArgs("UNASSIGNED").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).
+			AddRow("order1", "UNASSIGNED").
+			AddRow("order2", "UNASSIGNED"))
+	mock.ExpectBegin()
+	expectUnassignedLock("order1")
+	expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "order1", "dp2", "PENDING", "", "", now.Unix()+30)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+		WithArgs("OFFERED", now.Unix(), sqlmock.AnyArg(), "order1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "order1")
+	mock.ExpectCommit()
+	// Still nobody for order2, which stays UNASSIGNED without a status change.
+	mock.ExpectBegin()
+	expectUnassignedLock("order2")
+	expectNoCandidates(mock, "order2")
+	mock.ExpectCommit()
+
+	offered, err := service.RedispatchUnassigned(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, offered)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOfferStats(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectQuery(`SELECT status, COUNT\(\*\) AS count FROM "offers" WHERE delivery_person_id = \$1 GROUP BY "status"`).
+		WithArgs("dp1").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "count"}).
+			AddRow("ACCEPTED", 6).
+			AddRow("DECLINED", 3).
+			AddRow("EXPIRED", 1).
+			AddRow("PENDING", 1))
+
+	stats, err := service.OfferStats(context.Background(), "dp1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, OfferStats{Accepted: 6, Declined: 3, Expired: 1}, stats)
+	assert.InDelta(t, 0.6, stats.AcceptanceRate(), 1e-9)
+	assert.Zero(t, OfferStats{}.AcceptanceRate())
+}
@@ -0,0 +1,180 @@
+package fulfillment
+
+import "time"
+
+// Config holds the tunables of the fulfillment service.
+type Config struct {
+	// OfferTimeout is how long a delivery person has to answer an offer
+	// before it is passed on to the next candidate.
+	OfferTimeout time.Duration
+
+	// BatchWindow is how recently an assigned order must have been placed
+	// for a new order to be batched onto the same delivery person. Zero
+	// disables batching.
+	BatchWindow time.Duration
+	// BatchPickupRadiusMeters is how far apart two pickups may be to count
+	// as the same pickup.
+	BatchPickupRadiusMeters float64
+	// MaxDetourMeters caps the extra distance a batched order may add to the
+	// delivery person's trip.
+	MaxDetourMeters float64
+
+	// DispatchRingsMeters are the radii searched in turn for the nearest
+	// available delivery person. MaxDispatchRadiusMeters is the outermost
+	// ring; no one further away is offered an order. Zero removes the limit.
+	DispatchRingsMeters     []float64
+	MaxDispatchRadiusMeters float64
+
+	// DefaultSpeedKmh is the average travel speed used when a vehicle type
+	// has no entry in SpeedProfilesKmh.
+	DefaultSpeedKmh  float64
+	SpeedProfilesKmh map[string]float64
+	// CongestionFactors stretches travel times per zone ID; zones without an
+	// entry use 1.
+	CongestionFactors map[string]float64
+	// RoadDistanceFactor converts straight-line distance into the typical
+	// distance travelled on roads.
+	RoadDistanceFactor float64
+	// StopDwell is the time spent at each pickup or drop-off.
+	StopDwell time.Duration
+
+	// StaleAfter is how long a delivery person can go without a heartbeat or
+	// location report before they stop receiving offers. After OfflineAfter
+	// they are taken offline.
+	StaleAfter   time.Duration
+	OfflineAfter time.Duration
+
+	// MaxHandoffDistanceMeters is how far from the drop-off a delivery can
+	// be completed. Zero disables the check.
+	MaxHandoffDistanceMeters float64
+	// MaxPinAttempts is how many wrong delivery PINs are accepted before the
+	// PIN is locked.
+	MaxPinAttempts int
+
+	// RetryableExceptions are the delivery exception reasons worth another
+	// attempt, DeliveryRetryWait after the failed one. Once an order has
+	// failed MaxDeliveryAttempts times it is returned to its pickup.
+	RetryableExceptions []string
+	DeliveryRetryWait   time.Duration
+	MaxDeliveryAttempts int
+
+	// ScheduleLeadTime is the time allowed for a delivery person to reach
+	// the pickup when working out when to dispatch a scheduled order.
+	ScheduleLeadTime time.Duration
+
+	// SLATargets is the time from placement to delivery promised for each
+	// priority, used where no SLA target is stored for the order's zone.
+	// Orders are at risk once SLAAtRiskRatio of that time has passed or
+	// their ETA is past it.
+	SLATargets     map[string]time.Duration
+	SLAAtRiskRatio float64
+
+	// OutboxBatchSize caps how many events one relay pass publishes. A
+	// failed publish is retried after OutboxRetryBackoff, doubling with each
+	// attempt up to OutboxMaxBackoff; after OutboxMaxAttempts the event is
+	// dead-lettered.
+	OutboxBatchSize    int
+	OutboxMaxAttempts  int
+	OutboxRetryBackoff time.Duration
+	OutboxMaxBackoff   time.Duration
+
+	// WebhookBatchSize caps how many webhooks one dispatcher pass sends,
+	// each with WebhookTimeout to answer. Failed sends are retried like
+	// outbox events, and a subscription is disabled after
+	// WebhookDisableAfter failures in a row. Zero never disables.
+	WebhookBatchSize    int
+	WebhookTimeout      time.Duration
+	WebhookMaxAttempts  int
+	WebhookRetryBackoff time.Duration
+	WebhookMaxBackoff   time.Duration
+	WebhookDisableAfter int
+	// WebhookAllowInsecure lets webhooks go to plain http URLs and to
+	// loopback, private and link-local addresses. Only for development.
+	WebhookAllowInsecure bool
+
+	// InboxBatchSize caps how many order-created events one inbox pass
+	// handles. Failures are retried like outbox events; after
+	// InboxMaxAttempts the event is given up on.
+	InboxBatchSize    int
+	InboxMaxAttempts  int
+	InboxRetryBackoff time.Duration
+	InboxMaxBackoff   time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		OfferTimeout:            30 * time.Second,
+		BatchWindow:             10 * time.Minute,
+		BatchPickupRadiusMeters: 200,
+		MaxDetourMeters:         2000,
+		DispatchRingsMeters:     []float64{1000, 3000, 5000},
+		MaxDispatchRadiusMeters: 5000,
+		DefaultSpeedKmh:         20,
+		SpeedProfilesKmh: map[string]float64{
+			VehicleBike:    15,
+			VehicleScooter: 25,
+			VehicleCar:     30,
+			VehicleVan:     25,
+		},
+		RoadDistanceFactor:       1.3,
+		StopDwell:                2 * time.Minute,
+		StaleAfter:               2 * time.Minute,
+		OfflineAfter:             10 * time.Minute,
+		MaxHandoffDistanceMeters: 100,
+		MaxPinAttempts:           5,
+		RetryableExceptions:      []string{ExceptionCustomerUnreachable},
+		DeliveryRetryWait:        10 * time.Minute,
+		MaxDeliveryAttempts:      2,
+		ScheduleLeadTime:         15 * time.Minute,
+		SLATargets: map[string]time.Duration{
+			PriorityStandard: 60 * time.Minute,
+			PriorityExpress:  30 * time.Minute,
+			PriorityVIP:      45 * time.Minute,
+			PriorityMedical:  20 * time.Minute,
+		},
+		SLAAtRiskRatio:      0.8,
+		OutboxBatchSize:     100,
+		OutboxMaxAttempts:   10,
+		OutboxRetryBackoff:  time.Second,
+		OutboxMaxBackoff:    5 * time.Minute,
+		WebhookBatchSize:    100,
+		WebhookTimeout:      10 * time.Second,
+		WebhookMaxAttempts:  8,
+		WebhookRetryBackoff: 30 * time.Second,
+		WebhookMaxBackoff:   time.Hour,
+		WebhookDisableAfter: 20,
+		InboxBatchSize:      100,
+		InboxMaxAttempts:    10,
+		InboxRetryBackoff:   time.Second,
+		InboxMaxBackoff:     5 * time.Minute,
+	}
+}
+
+type Option func(*OrderService)
+
+func WithConfig(cfg Config) Option {
+	return func(s *OrderService) {
+		s.cfg = cfg
+	}
+}
+
+// WithNotifier sets where SLA alerts are sent.
+func WithNotifier(n Notifier) Option {
+	return func(s *OrderService) {
+		s.notifier = n
+	}
+}
+
+// WithPublisher sets where outbox events are published.
+func WithPublisher(p EventPublisher) Option {
+	return func(s *OrderService) {
+		s.publisher = p
+	}
+}
+
+// WithClock overrides the time source, which is mostly useful in tests.
+func WithClock(now func() time.Time) Option {
+	return func(s *OrderService) {
+		s.now = now
+	}
+}
@@ -0,0 +1,245 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"gorm.io/gorm"
+)
+
+// EventSchemaVersion is the version of the event schema in
+// proto/events.proto. It only changes when a change is not backwards
+// compatible.
+const EventSchemaVersion = 1
+
+const (
+	EventOrderAssigned         = "order.assigned"
+	EventOrderStatusChanged    = "order.status_changed"
+	EventDriverLocationUpdated = "driver.location_updated"
+	EventOrderCreated          = "order.created"
+)
+
+// EventPublisher sends domain events to other services. Events are
+// delivered at least once, so subscribers should de-duplicate by EventId.
+type EventPublisher interface {
+	Publish(ctx context.Context, event *pb.Event) error
+}
+
+// LogPublisher writes events to the logger in the context. It is used unless
+// another publisher is configured.
+type LogPublisher struct{}
+
+func (LogPublisher) Publish(ctx context.Context, event *pb.Event) error {
+	logging.FromContext(ctx).Info("event published",
+		"event_type", EventType(event), "event_id", event.EventId, "event", protojson.Format(event))
+	return nil
+}
+
+// EventType names the kind of event, for routing and filtering.
+func EventType(event *pb.Event) string {
+	switch event.Payload.(type) {
+	case *pb.Event_OrderAssigned:
+		return EventOrderAssigned
+	case *pb.Event_OrderStatusChanged:
+		return EventOrderStatusChanged
+	case *pb.Event_DriverLocationUpdated:
+		return EventDriverLocationUpdated
+	case *pb.Event_OrderCreated:
+		return EventOrderCreated
+	default:
+		return ""
+	}
+}
+
+// recordEvent writes an event to the outbox as part of tx, so the event
+// exists if and only if the change it describes commits. Events with the
+// same aggregateID are published in the order they were recorded.
+func (s *OrderService) recordEvent(tx *gorm.DB, aggregateID string, event *pb.Event) error {
+	event.EventId = newID()
+	event.SchemaVersion = EventSchemaVersion
+	event.OccurredAt = s.now().Unix()
+	payload, err := protojson.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&OutboxEvent{
+		EventID:     event.EventId,
+		AggregateID: aggregateID,
+		EventType:   EventType(event),
+		Payload:     string(payload),
+	}).Error
+}
+
+// recordOrderEvent records that an order was assigned or changed status.
+func (s *OrderService) recordOrderEvent(tx *gorm.DB, eventType, orderID, deliveryPersonID, status string) error {
+	event := &pb.Event{Payload: &pb.Event_OrderStatusChanged{OrderStatusChanged: &pb.OrderStatusChanged{
+		OrderId:          orderID,
+		DeliveryPersonId: deliveryPersonID,
+		Status:           status,
+	}}}
+	if eventType == EventOrderAssigned {
+		event.Payload = &pb.Event_OrderAssigned{OrderAssigned: &pb.OrderAssigned{
+			OrderId:          orderID,
+			DeliveryPersonId: deliveryPersonID,
+		}}
+	}
+	return s.recordEvent(tx, orderID, event)
+}
+
+// setOrderStatus moves an order to status and records the change.
+func (s *OrderService) setOrderStatus(tx *gorm.DB, order *Order, status string) error {
+	previous := *order
+	if err := tx.Model(order).Updates(map[string]interface{}{
+		"status":            status,
+		"status_changed_at": s.now().Unix(),
+	}).Error; err != nil {
+		return err
+	}
+	s.observeStatusChange(tx, &previous, status)
+	return s.recordOrderEvent(tx, EventOrderStatusChanged, order.OrderID, order.DeliveryPersonID, status)
+}
+
+// outboxRelayLock names the advisory lock held by the running relay.
+const outboxRelayLock = "fulfillment.outbox_relay"
+
+// RelayOutbox publishes pending outbox events oldest first. Events for the
+// same order are published in the order they were recorded: while one is
+// waiting to be retried, the ones after it wait too. An event that still
+// fails after OutboxMaxAttempts is moved to the dead-letter table. It
+// returns the number of events published.
+//
+// Only one relay runs at a time across every instance of the service; the
+// others return without publishing. The relay publishes while its
+// transaction holds the lock, on purpose: releasing it before the broker
+// answers would let another relay overtake it with later events of the same
+// order. The only rows it locks meanwhile are outbox rows, which nothing
+// else updates.
+func (s *OrderService) RelayOutbox(ctx context.Context) (int, error) {
+	published := 0
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var locked bool
+		if err := tx.Raw("SELECT pg_try_advisory_xact_lock(hashtext(?))", outboxRelayLock).Scan(&locked).Error; err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		now := s.now().Unix()
+		retrying := tx.Model(&OutboxEvent{}).
+			Select("aggregate_id").
+			Where("published_at = 0 AND next_attempt_at > ?", now)
+
+		var events []OutboxEvent
+		if err := tx.Where("published_at = 0 AND aggregate_id NOT IN (?)", retrying).
+			Order("id").
+			Limit(s.cfg.OutboxBatchSize).
+			Find(&events).Error; err != nil {
+			return err
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		var subscriptions []WebhookSubscription
+		if err := tx.Where("active = ?", true).Find(&subscriptions).Error; err != nil {
+			return err
+		}
+
+		failed := make(map[string]bool)
+		for i := range events {
+			event := &events[i]
+			if failed[event.AggregateID] {
+				continue
+			}
+
+			err := s.publishOutboxEvent(ctx, event)
+			if err == nil {
+				if err := s.enqueueWebhooks(tx, event, subscriptions); err != nil {
+					return err
+				}
+				if err := tx.Model(event).Update("published_at", now).Error; err != nil {
+					return err
+				}
+				published++
+				continue
+			}
+
+			failed[event.AggregateID] = true
+			if err := s.retryOrDeadLetter(tx, event, err); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return published, err
+}
+
+func (s *OrderService) publishOutboxEvent(ctx context.Context, row *OutboxEvent) error {
+	event := &pb.Event{}
+	if err := protojson.Unmarshal([]byte(row.Payload), event); err != nil {
+		return err
+	}
+	return s.publisher.Publish(ctx, event)
+}
+
+// retryOrDeadLetter records a failed publish, scheduling the next attempt
+// with exponential backoff or giving up on the event once it has used all
+// of its attempts.
+func (s *OrderService) retryOrDeadLetter(tx *gorm.DB, event *OutboxEvent, cause error) error {
+	event.Attempts++
+	if event.Attempts >= s.cfg.OutboxMaxAttempts {
+		logging.FromContext(tx.Statement.Context).Warn("giving up on event",
+			"event_id", event.EventID, "attempts", event.Attempts, "error", cause)
+		if err := tx.Create(&DeadLetterEvent{
+			EventID:     event.EventID,
+			AggregateID: event.AggregateID,
+			EventType:   event.EventType,
+			Payload:     event.Payload,
+			Attempts:    event.Attempts,
+			LastError:   cause.Error(),
+			CreatedAt:   event.CreatedAt,
+			FailedAt:    s.now().Unix(),
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(event).Error
+	}
+
+	return tx.Model(event).Updates(map[string]interface{}{
+		"attempts":        event.Attempts,
+		"next_attempt_at": s.now().Add(backoff(s.cfg.OutboxRetryBackoff, s.cfg.OutboxMaxBackoff, event.Attempts)).Unix(),
+		"last_error":      cause.Error(),
+	}).Error
+}
+
+// RunOutboxRelay calls RelayOutbox every interval until ctx is cancelled.
+func (s *OrderService) RunOutboxRelay(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.RelayOutbox(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to relay outbox", "error", err)
+			}
+		}
+	}
+}
+
+// backoff is how long to wait before retrying after the given number of
+// failed attempts: base, doubling with each attempt, capped at limit.
+func backoff(base, limit time.Duration, attempts int) time.Duration {
+	wait := base << (attempts - 1)
+	if wait <= 0 || wait > limit {
+		return limit
+	}
+	return wait
+}
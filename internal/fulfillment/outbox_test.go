@@ -0,0 +1,134 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+// expectEvent expects an event of eventType about orderID to be written to
+// the outbox.
+func expectEvent(mock sqlmock.Sqlmock, eventType, orderID string) {
+	mock.ExpectQuery(`INSERT INTO "outbox_events" \("event_id","aggregate_id","event_type","payload",.*\) RETURNING "id"`).
+		WithArgs(sqlmock.AnyArg(), orderID, eventType, sqlmock.AnyArg(), 0, 0, "", sqlmock.AnyArg(), 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+}
+
+// expectRelayLock expects the relay to try to take its lock and answers
+// whether it got it.
+func expectRelayLock(mock sqlmock.Sqlmock, locked bool) {
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock\(hashtext\(\$1\)\)`).
+		WithArgs("fulfillment.outbox_relay").
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_xact_lock"}).AddRow(locked))
+}
+
+func TestRelayOutbox(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	var sent []string
+	publisher := publisherFunc(func(ctx context.Context, event *pb.Event) error {
+		sent = append(sent, event.EventId)
+		if event.EventId == "e1" {
+			return errors.New("broker unavailable")
+		}
+		return nil
+	})
+	service := NewService(db, WithClock(func() time.Time { return now }), WithPublisher(publisher))
+
+	mock.ExpectBegin()
+	expectRelayLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM "outbox_events" WHERE published_at = 0 AND aggregate_id NOT IN \(SELECT "aggregate_id" FROM "outbox_events" WHERE published_at = 0 AND next_attempt_at > \$1\) ORDER BY id LIMIT \$2$`).
+		WithArgs(now.Unix(), 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "aggregate_id", "event_type", "payload", "attempts"}).
+			AddRow(1, "e1", "order1", EventOrderAssigned, `{"eventId":"e1","orderAssigned":{"orderId":"order1"}}`, 0).
+			AddRow(2, "e2", "order1", EventOrderStatusChanged, `{"eventId":"e2","orderStatusChanged":{"orderId":"order1","status":"IN_PROGRESS"}}`, 0).
+			AddRow(3, "e3", "order2", EventOrderAssigned, `{"eventId":"e3","orderAssigned":{"orderId":"order2"}}`, 0))
+	mock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions" WHERE active = \$1`).
+		WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "event_types", "active"}).
+			AddRow("sub1", EventOrderAssigned, true).
+			AddRow("sub2", EventOrderStatusChanged, true))
+	mock.ExpectExec(`UPDATE "outbox_events" SET "attempts"=\$1,"last_error"=\$2,"next_attempt_at"=\$3 WHERE "id" = \$4`).
+		WithArgs(1, "broker unavailable", now.Add(time.Second).Unix(), 1).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO "webhook_deliveries" .* ON CONFLICT DO NOTHING`).
+		WithArgs(sqlmock.AnyArg(), "sub1", "e3", EventOrderAssigned, `{"eventId":"e3","orderAssigned":{"orderId":"order2"}}`, "PENDING", 0, 0, 0, "", sqlmock.AnyArg(), 0).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE "outbox_events" SET "published_at"=\$1 WHERE "id" = \$2`).
+		WithArgs(now.Unix(), 3).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	published, err := service.RelayOutbox(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, published)
+	assert.Equal(t, []string{"e1", "e3"}, sent, "e2 waits for e1 to be retried")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutboxDeadLetters(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	publisher := publisherFunc(func(ctx context.Context, event *pb.Event) error {
+		return errors.New("rejected")
+	})
+	service := NewService(db, WithClock(func() time.Time { return now }), WithPublisher(publisher))
+
+	mock.ExpectBegin()
+	expectRelayLock(mock, true)
+	mock.ExpectQuery(`SELECT \* FROM "outbox_events"`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "event_id", "aggregate_id", "event_type", "payload", "attempts", "created_at"}).
+			AddRow(7, "e7", "order1", EventOrderAssigned, `{"eventId":"e7","orderAssigned":{"orderId":"order1"}}`, 9, now.Unix()-60))
+	mock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions"`).
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id"}))
+	mock.ExpectExec(`INSERT INTO "dead_letter_events"`).
+		WithArgs("e7", "order1", EventOrderAssigned, `{"eventId":"e7","orderAssigned":{"orderId":"order1"}}`, 10, "rejected", now.Unix()-60, now.Unix()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM "outbox_events" WHERE "outbox_events"\."id" = \$1`).
+		WithArgs(7).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	published, err := service.RelayOutbox(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutboxWhileAnotherRelayRuns(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	publisher := publisherFunc(func(ctx context.Context, event *pb.Event) error {
+		t.Errorf("published %s while another relay held the lock", event.EventId)
+		return nil
+	})
+	service := NewService(db, WithPublisher(publisher))
+
+	mock.ExpectBegin()
+	expectRelayLock(mock, false)
+	mock.ExpectCommit()
+
+	published, err := service.RelayOutbox(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+type publisherFunc func(ctx context.Context, event *pb.Event) error
+
+func (f publisherFunc) Publish(ctx context.Context, event *pb.Event) error {
+	return f(ctx, event)
+}
@@ -0,0 +1,163 @@
+package fulfillment
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"fullfillment-service/internal/auth"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const deliveryPinDigits = 4
+
+// pinReaders are the roles that may read a delivery PIN.
+var pinReaders = []string{auth.RoleOps, auth.RoleCustomerService, auth.RoleInternalService}
+
+// GetDeliveryPin returns the PIN the customer hands to the delivery person.
+// It is meant for the customer-facing API only and must never be exposed to
+// drivers, so it checks the caller itself rather than trusting the
+// interceptor's policy. With authentication off, like every RPC, it is open.
+func (s *OrderService) GetDeliveryPin(ctx context.Context, req *pb.GetDeliveryPinRequest) (*pb.GetDeliveryPinResponse, error) {
+	if identity := auth.FromContext(ctx); identity != nil && !slices.ContainsFunc(pinReaders, identity.HasRole) {
+		return nil, status.Error(codes.PermissionDenied, "only customer-facing callers may read delivery PINs")
+	}
+
+	var pin DeliveryPin
+	err := s.db.WithContext(ctx).First(&pin, "order_id = ?", req.OrderId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "delivery PIN not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetDeliveryPinResponse{OrderId: pin.OrderID, Pin: pin.Pin}, nil
+}
+
+// VerifyDeliveryPin checks the PIN a delivery person collected at the door.
+// After MaxPinAttempts wrong guesses the PIN is locked and only ops can
+// release the order with OverrideDeliveryPin.
+func (s *OrderService) VerifyDeliveryPin(ctx context.Context, req *pb.VerifyDeliveryPinRequest) (*pb.VerifyDeliveryPinResponse, error) {
+	var pin DeliveryPin
+	wrong := false
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		var order Order
+		err := tx.First(&order, "order_id = ?", req.OrderId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "order not found")
+		}
+		if err != nil {
+			return err
+		}
+		if order.DeliveryPersonID != req.DeliveryPersonId {
+			return status.Error(codes.PermissionDenied, "order is assigned to another delivery person")
+		}
+
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pin, "order_id = ?", req.OrderId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "delivery PIN not found")
+		}
+		if err != nil {
+			return err
+		}
+		if pin.VerifiedAt != 0 {
+			return nil
+		}
+		if pin.Attempts >= s.cfg.MaxPinAttempts {
+			return status.Error(codes.ResourceExhausted, "too many wrong PIN attempts, contact ops")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(req.Pin), []byte(pin.Pin)) == 1 {
+			pin.VerifiedAt = s.now().Unix()
+			return tx.Model(&pin).Update("verified_at", pin.VerifiedAt).Error
+		}
+		wrong = true
+		pin.Attempts++
+		return tx.Model(&pin).Update("attempts", pin.Attempts).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if wrong {
+		return nil, status.Errorf(codes.PermissionDenied, "wrong PIN, %d attempts left", max(s.cfg.MaxPinAttempts-pin.Attempts, 0))
+	}
+
+	return &pb.VerifyDeliveryPinResponse{Status: "VERIFIED"}, nil
+}
+
+// OverrideDeliveryPin lets ops release an order for delivery without the
+// customer's PIN, recording who did it and why. When callers are
+// authenticated the operator is the caller, whatever the request says.
+func (s *OrderService) OverrideDeliveryPin(ctx context.Context, req *pb.OverrideDeliveryPinRequest) (*pb.OverrideDeliveryPinResponse, error) {
+	operator := req.OperatorId
+	if identity := auth.FromContext(ctx); identity != nil {
+		if !identity.HasRole(auth.RoleOps) {
+			return nil, status.Error(codes.PermissionDenied, "only ops may override a delivery PIN")
+		}
+		operator = identity.Subject
+	}
+	if operator == "" || req.Reason == "" {
+		return nil, status.Error(codes.InvalidArgument, "operator and reason are required")
+	}
+
+	result := s.db.WithContext(ctx).Model(&DeliveryPin{}).
+		Where("order_id = ?", req.OrderId).
+		Updates(map[string]interface{}{
+			"verified_at":     s.now().Unix(),
+			"overridden_by":   operator,
+			"override_reason": req.Reason,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, status.Error(codes.NotFound, "delivery PIN not found")
+	}
+	return &pb.OverrideDeliveryPinResponse{Status: "OVERRIDDEN"}, nil
+}
+
+// issueDeliveryPin gives an order its PIN when it is first assigned. An order
+// that is reassigned keeps the PIN the customer already has.
+func issueDeliveryPin(tx *gorm.DB, orderID string) error {
+	pin, err := newPin()
+	if err != nil {
+		return err
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&DeliveryPin{OrderID: orderID, Pin: pin}).Error
+}
+
+// requireVerifiedPin fails unless the order's PIN has been verified or
+// overridden. Orders assigned before PINs were introduced have none and are
+// let through.
+func requireVerifiedPin(tx *gorm.DB, orderID string) error {
+	var pins []DeliveryPin
+	if err := tx.Where("order_id = ?", orderID).Limit(1).Find(&pins).Error; err != nil {
+		return err
+	}
+	if len(pins) == 0 || pins[0].VerifiedAt != 0 {
+		return nil
+	}
+	return status.Error(codes.FailedPrecondition, "delivery PIN has not been verified")
+}
+
+func newPin() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < deliveryPinDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", deliveryPinDigits, n), nil
+}
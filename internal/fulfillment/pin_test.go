@@ -0,0 +1,273 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"fullfillment-service/internal/auth"
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expectPinIssued expects a PIN to be created for orderID unless it already
+// has one.
+func expectPinIssued(mock sqlmock.Sqlmock, orderID string) {
+	mock.ExpectExec(`INSERT INTO "delivery_pins" .* ON CONFLICT DO NOTHING`).
+		WithArgs(insertArgs(&DeliveryPin{}, orderID)...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+// expectPinLookup expects orderID's PIN to be read and answers with one
+// verified at verifiedAt, or not yet verified when it is zero.
+func expectPinLookup(mock sqlmock.Sqlmock, orderID string, verifiedAt int64) {
+	mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1 LIMIT \$2`).
+		WithArgs(orderID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin", "verified_at"}).AddRow(orderID, "0420", verifiedAt))
+}
+
+func TestNewPin(t *testing.T) {
+	for i := 0; i < 20; i++ {
+		pin, err := newPin()
+
+		assert.NoError(t, err)
+		assert.Regexp(t, `^\d{4}$`, pin)
+	}
+}
+
+func TestVerifyDeliveryPin(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	expectLookups := func(orderID string, attempts int) {
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+			WithArgs(orderID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow(orderID, "dp1", "IN_PROGRESS"))
+		mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1 .* FOR UPDATE`).
+			WithArgs(orderID, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin", "attempts"}).AddRow(orderID, "0420", attempts))
+	}
+
+	t.Run("Success - Matching PIN", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLookups("order1", 0)
+		mock.ExpectExec(`UPDATE "delivery_pins" SET "verified_at"=\$1 WHERE "order_id" = \$2`).
+			WithArgs(now.Unix(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.VerifyDeliveryPin(context.Background(), &pb.VerifyDeliveryPinRequest{OrderId: "order1", DeliveryPersonId: "dp1", Pin: "0420"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "VERIFIED", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Wrong PIN Counts The Attempt", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLookups("order2", 1)
+		mock.ExpectExec(`UPDATE "delivery_pins" SET "attempts"=\$1 WHERE "order_id" = \$2`).
+			WithArgs(2, "order2").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.VerifyDeliveryPin(context.Background(), &pb.VerifyDeliveryPinRequest{OrderId: "order2", DeliveryPersonId: "dp1", Pin: "1234"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.Contains(t, err.Error(), "3 attempts left")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Locked After Too Many Attempts", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectLookups("order3", 5)
+		mock.ExpectRollback()
+
+		resp, err := service.VerifyDeliveryPin(context.Background(), &pb.VerifyDeliveryPinRequest{OrderId: "order3", DeliveryPersonId: "dp1", Pin: "0420"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Someone Else's Order", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+			WithArgs("order4", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id"}).AddRow("order4", "dp1"))
+		mock.ExpectRollback()
+
+		resp, err := service.VerifyDeliveryPin(context.Background(), &pb.VerifyDeliveryPinRequest{OrderId: "order4", DeliveryPersonId: "dp2", Pin: "0420"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestOverrideDeliveryPin(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "delivery_pins" SET "overridden_by"=\$1,"override_reason"=\$2,"verified_at"=\$3 WHERE order_id = \$4`).
+			WithArgs("ops7", "customer confirmed by phone", now.Unix(), "order1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.OverrideDeliveryPin(context.Background(), &pb.OverrideDeliveryPinRequest{
+			OrderId:    "order1",
+			OperatorId: "ops7",
+			Reason:     "customer confirmed by phone",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "OVERRIDDEN", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Success - Operator Is The Caller", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "delivery_pins" SET "overridden_by"=\$1,"override_reason"=\$2,"verified_at"=\$3 WHERE order_id = \$4`).
+			WithArgs("ops-jane", "customer confirmed by phone", now.Unix(), "order1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "ops-jane", Roles: []string{auth.RoleOps}})
+		resp, err := service.OverrideDeliveryPin(ctx, &pb.OverrideDeliveryPinRequest{
+			OrderId:    "order1",
+			OperatorId: "someone-else",
+			Reason:     "customer confirmed by phone",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "OVERRIDDEN", resp.Status)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Not Ops", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "dp1", Roles: []string{auth.RoleDriver}, DeliveryPersonID: "dp1"})
+		resp, err := service.OverrideDeliveryPin(ctx, &pb.OverrideDeliveryPinRequest{OrderId: "order1", OperatorId: "ops7", Reason: "trust me"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Failure - Reason Required", func(t *testing.T) {
+		resp, err := service.OverrideDeliveryPin(context.Background(), &pb.OverrideDeliveryPinRequest{OrderId: "order1", OperatorId: "ops7"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGetDeliveryPin(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Customer Service", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1`).
+			WithArgs("order1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin"}).AddRow("order1", "0420"))
+
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "support", Roles: []string{auth.RoleCustomerService}})
+		resp, err := service.GetDeliveryPin(ctx, &pb.GetDeliveryPinRequest{OrderId: "order1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0420", resp.Pin)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Driver", func(t *testing.T) {
+		ctx := auth.WithIdentity(context.Background(), &auth.Identity{Subject: "dp1", Roles: []string{auth.RoleDriver}, DeliveryPersonID: "dp1"})
+		resp, err := service.GetDeliveryPin(ctx, &pb.GetDeliveryPinRequest{OrderId: "order1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("Success - Authentication Disabled", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1`).
+			WithArgs("order1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin"}).AddRow("order1", "0420"))
+
+		resp, err := service.GetDeliveryPin(context.Background(), &pb.GetDeliveryPinRequest{OrderId: "order1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "0420", resp.Pin)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestDeliveryPinWithAuthDisabled walks an order through the PIN handoff
+// without authentication, the default configuration.
+func TestDeliveryPinWithAuthDisabled(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+	ctx := context.Background()
+
+	mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1`).
+		WithArgs("order1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin"}).AddRow("order1", "0420"))
+
+	pin, err := service.GetDeliveryPin(ctx, &pb.GetDeliveryPinRequest{OrderId: "order1"})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+		WithArgs("order1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status"}).AddRow("order1", "dp1", "IN_PROGRESS"))
+	mock.ExpectQuery(`SELECT \* FROM "delivery_pins" WHERE order_id = \$1 .* FOR UPDATE`).
+		WithArgs("order1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "pin", "attempts"}).AddRow("order1", "0420", 0))
+	mock.ExpectExec(`UPDATE "delivery_pins" SET "verified_at"=\$1 WHERE "order_id" = \$2`).
+		WithArgs(now.Unix(), "order1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	_, err = service.VerifyDeliveryPin(ctx, &pb.VerifyDeliveryPinRequest{OrderId: "order1", DeliveryPersonId: "dp1", Pin: pin.Pin})
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	expectOrderLock(mock, "order1")
+	expectPinLookup(mock, "order1", now.Unix())
+	mock.ExpectExec(`INSERT INTO "proof_of_deliveries"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+		WithArgs("DELIVERED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "order1")
+	expectDriverStatusRefresh(mock, "dp1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+	mock.ExpectCommit()
+
+	resp, err := service.CompleteDelivery(ctx, &pb.CompleteDeliveryRequest{
+		OrderId:          "order1",
+		DeliveryPersonId: "dp1",
+		PhotoHash:        "sha256:abc",
+		Location:         &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "DELIVERED", resp.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,129 @@
+package fulfillment
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	PriorityStandard = "STANDARD"
+	PriorityExpress  = "EXPRESS"
+	PriorityVIP      = "VIP"
+	PriorityMedical  = "MEDICAL"
+)
+
+// priorityRanks orders the priorities from least to most urgent. Orders
+// stored before priorities existed have an empty priority and rank as
+// standard.
+var priorityRanks = map[string]int{
+	PriorityStandard: 0,
+	PriorityExpress:  1,
+	PriorityVIP:      2,
+	PriorityMedical:  3,
+}
+
+// priorityOrder sorts orders most urgent first, matching priorityRanks.
+const priorityOrder = "CASE priority WHEN '" + PriorityMedical + "' THEN 3 WHEN '" + PriorityVIP + "' THEN 2 WHEN '" + PriorityExpress + "' THEN 1 ELSE 0 END DESC"
+
+// lowerPriorities lists the priorities an order of the given priority may
+// preempt.
+func lowerPriorities(priority string) []string {
+	var lower []string
+	for p, rank := range priorityRanks {
+		if rank < priorityRanks[priority] {
+			lower = append(lower, p)
+		}
+	}
+	return lower
+}
+
+// preemptSavePoint marks where preemptCandidate can roll back to if the
+// preempted order finds no one else.
+const preemptSavePoint = "preempt"
+
+// preemptCandidate is the last resort for urgent orders nobody is free to
+// take: it finds the nearest delivery person holding an assigned, not yet
+// picked up order of lower priority who could carry the urgent order instead,
+// offers that order to someone else and returns the delivery person so the
+// urgent order can be offered to them. It returns an empty string when
+// nothing can be preempted, including when nobody else could take the
+// preempted order.
+func (s *OrderService) preemptCandidate(tx *gorm.DB, order *Order, pickup Point, asked *gorm.DB) (string, error) {
+	lower := lowerPriorities(order.Priority)
+	if len(lower) == 0 {
+		return "", nil
+	}
+
+	query := tx.Model(&Order{}).
+		Select("orders.*").
+		Joins("JOIN delivery_people ON delivery_people.delivery_person_id = orders.delivery_person_id").
+		Where("orders.status = ? AND orders.priority IN ?", OrderStatusAssigned, lower).
+		Where("delivery_people.status IN ?", []string{DeliveryPersonAvailable, DeliveryPersonBusy}).
+		Where("orders.delivery_person_id NOT IN (?)", asked)
+	if limit := s.cfg.MaxDispatchRadiusMeters; limit > 0 {
+		query = query.Where("ST_DWithin(delivery_people.location, ?::geography, ?)", pickup, limit)
+	}
+
+	var victim Order
+	err := query.
+		Scopes(onShift, inZone(order), withSuitableVehicle(order), withSpareCapacityInPlaceOf(order)).
+		Clauses(clause.OrderBy{Expression: clause.Expr{SQL: "ST_Distance(delivery_people.location, ?::geography)", Vars: []interface{}{pickup}}}).
+		Take(&victim).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The victim is only taken away if it can be offered to someone else
+	// straight away; otherwise everything below is undone and the urgent
+	// order waits for a driver like any other.
+	if err := tx.SavePoint(preemptSavePoint).Error; err != nil {
+		return "", err
+	}
+	deliveryPersonID := victim.DeliveryPersonID
+	previous := victim
+	result := tx.Model(&victim).
+		Where("status = ? AND delivery_person_id = ?", OrderStatusAssigned, deliveryPersonID).
+		Updates(map[string]interface{}{
+			"status":             OrderStatusOffered,
+			"status_changed_at":  s.now().Unix(),
+			"delivery_person_id": nil,
+			"batch_id":           "",
+		})
+	if result.Error != nil {
+		return "", result.Error
+	}
+	if result.RowsAffected == 0 {
+		// Picked up or reassigned since it was read.
+		return "", nil
+	}
+
+	// The preempted delivery person already accepted this order once, so
+	// it goes to someone else.
+	next, err := s.offerNext(tx, &victim)
+	if err != nil {
+		return "", err
+	}
+	if next == nil {
+		return "", tx.RollbackTo(preemptSavePoint).Error
+	}
+
+	if err := leaveBatch(tx, &previous); err != nil {
+		return "", err
+	}
+	s.observeStatusChange(tx, &previous, OrderStatusOffered)
+	if err := s.recordOrderEvent(tx, EventOrderStatusChanged, victim.OrderID, "", OrderStatusOffered); err != nil {
+		return "", err
+	}
+	if err := refreshDriverStatus(tx, deliveryPersonID); err != nil {
+		return "", err
+	}
+	if err := s.planRoute(tx, deliveryPersonID); err != nil {
+		return "", err
+	}
+	return deliveryPersonID, nil
+}
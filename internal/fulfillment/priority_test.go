@@ -0,0 +1,164 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLowerPriorities(t *testing.T) {
+	assert.Empty(t, lowerPriorities(PriorityStandard))
+	assert.ElementsMatch(t, []string{PriorityStandard}, lowerPriorities(PriorityExpress))
+	assert.ElementsMatch(t, []string{PriorityStandard, PriorityExpress, PriorityVIP}, lowerPriorities(PriorityMedical))
+}
+
+func TestAssignOrderRejectsUnknownPriority(t *testing.T) {
+	service := NewService(nil)
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order1", Priority: "URGENT"})
+
+	assert.Equal(t, "FAILED", resp.Status)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+// expectVictimQuery expects the search for an order to preempt.
+func expectVictimQuery(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+	return mock.ExpectQuery(`SELECT orders\.\* FROM "orders" JOIN delivery_people .* WHERE \(orders\.status = \$1 AND orders\.priority IN \(\$2\)\) AND delivery_people\.status IN \(\$3,\$4\) AND orders\.delivery_person_id NOT IN \(.*\) AND ST_DWithin\(delivery_people\.location, \$7::geography, \$8\) .* AND \(delivery_people\.max_weight_kg = 0 OR .*FROM orders AS held.* \+ orders\.weight_kg >= \$\d+\) AND \(delivery_people\.max_volume_liters = 0 OR .* \+ orders\.volume_liters >= \$\d+\) ORDER BY ST_Distance\(delivery_people\.location, \$\d+::geography\) LIMIT`)
+}
+
+// expectPreemption expects orderID to be taken off deliveryPersonID and put
+// back on offer.
+func expectPreemption(mock sqlmock.Sqlmock, orderID, deliveryPersonID string) {
+	mock.ExpectExec(`SAVEPOINT preempt`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE "orders" SET "batch_id"=\$1,"delivery_person_id"=\$2,"status"=\$3,"status_changed_at"=\$4,"updated_at"=\$5 WHERE \(status = \$6 AND delivery_person_id = \$7\) AND "order_id" = \$8`).
+		WithArgs("", nil, "OFFERED", sqlmock.AnyArg(), sqlmock.AnyArg(), "ASSIGNED", deliveryPersonID, orderID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+}
+
+func TestExpressOrderPreemptsStandardAssignment(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	expectZoneLookup(mock, "")
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectNoCandidates(mock, "express1")
+	expectVictimQuery(mock).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "priority"}).
+			AddRow("standard1", "dp1", "ASSIGNED", "STANDARD"))
+	expectPreemption(mock, "standard1", "dp1")
+	expectCandidateQuery(mock, "standard1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "standard1", "dp2", "PENDING")...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "standard1")
+	expectDriverStatusRefresh(mock, "dp1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "express1", "dp1", "PENDING")...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOfferEstimate(mock, "dp1", nearbyHouse)
+	mock.ExpectCommit()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+		OrderId:  "express1",
+		Pickup:   &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		Dropoff:  &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+		Priority: "EXPRESS",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dp1", resp.DeliveryPersonId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPreemptionNeedsSomeoneElseForTheVictim(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	expectZoneLookup(mock, "")
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectNoCandidates(mock, "express1")
+	expectVictimQuery(mock).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "priority"}).
+			AddRow("standard1", "dp1", "ASSIGNED", "STANDARD"))
+	expectPreemption(mock, "standard1", "dp1")
+	expectNoCandidates(mock, "standard1")
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT preempt`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+		OrderId:  "express1",
+		Pickup:   &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		Dropoff:  &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+		Priority: "EXPRESS",
+	})
+
+	assert.Equal(t, "FAILED", resp.Status)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPreemptionSplitsVictimsBatch(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	expectZoneLookup(mock, "")
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectNoCandidates(mock, "express1")
+	expectVictimQuery(mock).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "priority", "batch_id"}).
+			AddRow("standard1", "dp1", "ASSIGNED", "STANDARD", "standard1"))
+	expectPreemption(mock, "standard1", "dp1")
+	expectCandidateQuery(mock, "standard1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "standard1", "dp2", "PENDING")...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT "order_id" FROM "orders" WHERE batch_id = \$1 AND order_id <> \$2 ORDER BY created_at`).
+		WithArgs("standard1", "standard1").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id"}).AddRow("standard2"))
+	mock.ExpectExec(`UPDATE "orders" SET "batch_id"=\$1,"updated_at"=\$2 WHERE order_id IN \(\$3\)`).
+		WithArgs("", sqlmock.AnyArg(), "standard2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "standard1")
+	expectDriverStatusRefresh(mock, "dp1").WillReturnResult(sqlmock.NewResult(1, 1))
+	expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}).AddRow("standard2"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WithArgs(insertArgs(&Offer{}, sqlmock.AnyArg(), "express1", "dp1", "PENDING")...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOfferEstimate(mock, "dp1", nearbyHouse)
+	mock.ExpectCommit()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+		OrderId:  "express1",
+		Pickup:   &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		Dropoff:  &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+		Priority: "EXPRESS",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dp1", resp.DeliveryPersonId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,101 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// CompleteDelivery marks an order delivered, storing the evidence of the
+// handoff. The delivery person must be within MaxHandoffDistanceMeters of
+// the drop-off when it is captured and must have verified the customer's
+// PIN.
+func (s *OrderService) CompleteDelivery(ctx context.Context, req *pb.CompleteDeliveryRequest) (*pb.CompleteDeliveryResponse, error) {
+	if req.Location == nil {
+		return nil, status.Error(codes.InvalidArgument, "location is required")
+	}
+	if req.PhotoHash == "" && len(req.Signature) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "a photo or signature is required")
+	}
+
+	var proof ProofOfDelivery
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		order, err := s.lockDriverOrder(tx, req.OrderId, req.DeliveryPersonId, OrderStatusInProgress)
+		if err != nil {
+			return err
+		}
+		if err := requireVerifiedPin(tx, order.OrderID); err != nil {
+			return err
+		}
+
+		proof = ProofOfDelivery{
+			OrderID:          order.OrderID,
+			DeliveryPersonID: order.DeliveryPersonID,
+			PhotoHash:        req.PhotoHash,
+			PhotoRef:         req.PhotoRef,
+			RecipientName:    req.RecipientName,
+			Signature:        req.Signature,
+			Location:         pointFromProto(req.Location),
+			CapturedAt:       s.now().Unix(),
+		}
+		if order.Dropoff != nil {
+			proof.DistanceMeters = distanceMeters(*proof.Location, *order.Dropoff)
+			if s.cfg.MaxHandoffDistanceMeters > 0 && proof.DistanceMeters > s.cfg.MaxHandoffDistanceMeters {
+				return status.Errorf(codes.FailedPrecondition, "delivery person is %.0f m from the drop-off", proof.DistanceMeters)
+			}
+		}
+		if err := tx.Create(&proof).Error; err != nil {
+			return err
+		}
+
+		previous := *order
+		updates := map[string]interface{}{"status": OrderStatusDelivered, "status_changed_at": proof.CapturedAt}
+		if outcome := windowOutcome(order, proof.CapturedAt); outcome != "" {
+			updates["window_outcome"] = outcome
+		}
+		if err := tx.Model(order).Updates(updates).Error; err != nil {
+			return err
+		}
+		s.observeStatusChange(tx, &previous, OrderStatusDelivered)
+		if err := s.recordOrderEvent(tx, EventOrderStatusChanged, order.OrderID, order.DeliveryPersonID, OrderStatusDelivered); err != nil {
+			return err
+		}
+		if err := refreshDriverStatus(tx, order.DeliveryPersonID); err != nil {
+			return err
+		}
+		return s.planRoute(tx, order.DeliveryPersonID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CompleteDeliveryResponse{Status: OrderStatusDelivered, DistanceMeters: proof.DistanceMeters}, nil
+}
+
+func (s *OrderService) GetProofOfDelivery(ctx context.Context, req *pb.GetProofOfDeliveryRequest) (*pb.GetProofOfDeliveryResponse, error) {
+	var proof ProofOfDelivery
+	err := s.db.WithContext(ctx).First(&proof, "order_id = ?", req.OrderId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "proof of delivery not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.GetProofOfDeliveryResponse{
+		OrderId:          proof.OrderID,
+		DeliveryPersonId: proof.DeliveryPersonID,
+		PhotoHash:        proof.PhotoHash,
+		PhotoRef:         proof.PhotoRef,
+		RecipientName:    proof.RecipientName,
+		Signature:        proof.Signature,
+		Location:         pointToProto(proof.Location),
+		DistanceMeters:   proof.DistanceMeters,
+		CapturedAt:       proof.CapturedAt,
+	}, nil
+}
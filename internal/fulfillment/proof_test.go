@@ -0,0 +1,159 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expectOrderLock expects orderID to be loaded for update and answers with an
+// order in progress with dp1 headed for nearbyHouse.
+func expectOrderLock(mock sqlmock.Sqlmock, orderID string) {
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1 ORDER BY .* LIMIT \$2 FOR UPDATE`).
+		WithArgs(orderID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "dropoff"}).
+			AddRow(orderID, "dp1", "IN_PROGRESS", ewkb(nearbyHouse)))
+}
+
+func TestCompleteDelivery(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Stores Proof And Marks Delivered", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOrderLock(mock, "order1")
+		expectPinLookup(mock, "order1", now.Unix())
+		mock.ExpectExec(`INSERT INTO "proof_of_deliveries"`).
+			WithArgs("order1", "dp1", "sha256:abc", "s3://pod/order1.jpg", "Ada", []byte("sig"), sqlmock.AnyArg(), sqlmock.AnyArg(), now.Unix()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+			WithArgs("DELIVERED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectEvent(mock, "order.status_changed", "order1")
+		expectDriverStatusRefresh(mock, "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectRoutePlan(mock, "dp1", sqlmock.NewRows([]string{"order_id"}))
+		mock.ExpectCommit()
+
+		resp, err := service.CompleteDelivery(context.Background(), &pb.CompleteDeliveryRequest{
+			OrderId:          "order1",
+			DeliveryPersonId: "dp1",
+			PhotoHash:        "sha256:abc",
+			PhotoRef:         "s3://pod/order1.jpg",
+			RecipientName:    "Ada",
+			Signature:        []byte("sig"),
+			Location:         &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "DELIVERED", resp.Status)
+		assert.InDelta(t, distanceMeters(nextDoor, nearbyHouse), resp.DistanceMeters, 0.001)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Too Far From Drop-off", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOrderLock(mock, "order2")
+		expectPinLookup(mock, "order2", now.Unix())
+		mock.ExpectRollback()
+
+		resp, err := service.CompleteDelivery(context.Background(), &pb.CompleteDeliveryRequest{
+			OrderId:          "order2",
+			DeliveryPersonId: "dp1",
+			PhotoHash:        "sha256:abc",
+			Location:         &pb.Location{Lat: brooklyn.Lat, Lng: brooklyn.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - PIN Not Verified", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOrderLock(mock, "order5")
+		expectPinLookup(mock, "order5", 0)
+		mock.ExpectRollback()
+
+		resp, err := service.CompleteDelivery(context.Background(), &pb.CompleteDeliveryRequest{
+			OrderId:          "order5",
+			DeliveryPersonId: "dp1",
+			PhotoHash:        "sha256:abc",
+			Location:         &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "PIN")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Someone Else's Order", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectOrderLock(mock, "order3")
+		mock.ExpectRollback()
+
+		resp, err := service.CompleteDelivery(context.Background(), &pb.CompleteDeliveryRequest{
+			OrderId:          "order3",
+			DeliveryPersonId: "dp2",
+			PhotoHash:        "sha256:abc",
+			Location:         &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - No Evidence", func(t *testing.T) {
+		resp, err := service.CompleteDelivery(context.Background(), &pb.CompleteDeliveryRequest{
+			OrderId:  "order4",
+			Location: &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGetProofOfDelivery(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT \* FROM "proof_of_deliveries" WHERE order_id = \$1`).
+			WithArgs("order1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "photo_hash", "recipient_name", "signature", "location", "distance_meters", "captured_at"}).
+				AddRow("order1", "dp1", "sha256:abc", "Ada", []byte("sig"), ewkb(nextDoor), 45.2, 1700000000))
+
+		resp, err := service.GetProofOfDelivery(context.Background(), &pb.GetProofOfDeliveryRequest{OrderId: "order1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Ada", resp.RecipientName)
+		assert.Equal(t, []byte("sig"), resp.Signature)
+		assert.Equal(t, nextDoor.Lat, resp.Location.Lat)
+		assert.Equal(t, int64(1700000000), resp.CapturedAt)
+	})
+
+	t.Run("Failure - Not Found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT \* FROM "proof_of_deliveries" WHERE order_id = \$1`).
+			WithArgs("order2", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"order_id"}))
+
+		resp, err := service.GetProofOfDelivery(context.Background(), &pb.GetProofOfDeliveryRequest{OrderId: "order2"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
@@ -0,0 +1,214 @@
+package fulfillment
+
+import (
+	"context"
+	"fmt"
+
+	pb "fullfillment-service/proto"
+
+	"gorm.io/gorm"
+)
+
+const (
+	StopPickup  = "PICKUP"
+	StopDropoff = "DROPOFF"
+	// StopReturn takes an undeliverable order back to its pickup.
+	StopReturn = "RETURN"
+
+	// maxRouteImprovementPasses bounds the 2-opt search so planning stays
+	// cheap even for unusually long routes.
+	maxRouteImprovementPasses = 50
+)
+
+func (s *OrderService) GetDriverRoute(ctx context.Context, req *pb.GetDriverRouteRequest) (*pb.GetDriverRouteResponse, error) {
+	var stops []RouteStop
+	if err := s.db.WithContext(ctx).
+		Where("delivery_person_id = ?", req.DeliveryPersonId).
+		Order("sequence").
+		Find(&stops).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &pb.GetDriverRouteResponse{}
+	for _, stop := range stops {
+		resp.Stops = append(resp.Stops, &pb.RouteStop{
+			Sequence:       int32(stop.Sequence),
+			OrderId:        stop.OrderID,
+			Kind:           stop.Kind,
+			Location:       pointToProto(stop.Location),
+			DistanceMeters: stop.LegMeters,
+		})
+		resp.TotalDistanceMeters += stop.LegMeters
+	}
+	return resp, nil
+}
+
+// planRoute recomputes and stores the stop sequence for a delivery person's
+// active orders. It is called whenever an order is added to or leaves their
+// workload.
+func (s *OrderService) planRoute(tx *gorm.DB, deliveryPersonID string) error {
+	var driver DeliveryPerson
+	if err := tx.First(&driver, "delivery_person_id = ?", deliveryPersonID).Error; err != nil {
+		return fmt.Errorf("failed to find delivery person: %w", err)
+	}
+
+	var orders []Order
+	if err := tx.Where("delivery_person_id = ? AND status IN ?", deliveryPersonID, activeOrderStatuses).
+		Order("created_at").
+		Find(&orders).Error; err != nil {
+		return err
+	}
+
+	var stops []routeStop
+	for _, order := range orders {
+		if order.Status == OrderStatusReturning {
+			if order.Pickup != nil {
+				stops = append(stops, routeStop{orderID: order.OrderID, kind: StopReturn, location: *order.Pickup})
+			}
+			continue
+		}
+		if order.Dropoff == nil {
+			continue
+		}
+		if order.Status == OrderStatusAssigned && order.Pickup != nil {
+			stops = append(stops, routeStop{orderID: order.OrderID, kind: StopPickup, location: *order.Pickup})
+		}
+		stops = append(stops, routeStop{orderID: order.OrderID, kind: StopDropoff, location: *order.Dropoff})
+	}
+
+	if err := tx.Where("delivery_person_id = ?", deliveryPersonID).Delete(&RouteStop{}).Error; err != nil {
+		return err
+	}
+	if len(stops) == 0 {
+		return nil
+	}
+
+	start := stops[0].location
+	if driver.Location != nil {
+		start = *driver.Location
+	}
+
+	planned := sequenceStops(start, stops)
+	rows := make([]RouteStop, len(planned))
+	prev := start
+	for i, stop := range planned {
+		location := stop.location
+		rows[i] = RouteStop{
+			DeliveryPersonID: deliveryPersonID,
+			Sequence:         i + 1,
+			OrderID:          stop.orderID,
+			Kind:             stop.kind,
+			Location:         &location,
+			LegMeters:        distanceMeters(prev, location),
+		}
+		prev = location
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return err
+	}
+	return s.updateETAs(tx, &driver, rows)
+}
+
+type routeStop struct {
+	orderID  string
+	kind     string
+	location Point
+}
+
+// sequenceStops orders stops into a short path from start using a
+// nearest-neighbour tour refined with 2-opt, never visiting an order's
+// drop-off before its pickup.
+func sequenceStops(start Point, stops []routeStop) []routeStop {
+	route := nearestNeighbourRoute(start, stops)
+
+	for pass := 0; pass < maxRouteImprovementPasses; pass++ {
+		improved := false
+		for i := 0; i < len(route)-1; i++ {
+			for j := i + 1; j < len(route); j++ {
+				candidate := reversed(route, i, j)
+				if !respectsPickupOrder(candidate) {
+					continue
+				}
+				if routeLength(start, candidate) < routeLength(start, route)-1e-6 {
+					route = candidate
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+	return route
+}
+
+func nearestNeighbourRoute(start Point, stops []routeStop) []routeStop {
+	pickedUp := make(map[string]bool)
+	for _, stop := range stops {
+		if stop.kind == StopPickup {
+			pickedUp[stop.orderID] = false
+		}
+	}
+
+	remaining := append([]routeStop(nil), stops...)
+	route := make([]routeStop, 0, len(stops))
+	current := start
+	for len(remaining) > 0 {
+		best := -1
+		for i, stop := range remaining {
+			if done, hasPickup := pickedUp[stop.orderID]; stop.kind == StopDropoff && hasPickup && !done {
+				continue
+			}
+			if best == -1 || distanceMeters(current, stop.location) < distanceMeters(current, remaining[best].location) {
+				best = i
+			}
+		}
+
+		next := remaining[best]
+		if next.kind == StopPickup {
+			pickedUp[next.orderID] = true
+		}
+		route = append(route, next)
+		current = next.location
+		remaining = append(remaining[:best], remaining[best+1:]...)
+	}
+	return route
+}
+
+func reversed(route []routeStop, i, j int) []routeStop {
+	out := append([]routeStop(nil), route...)
+	for l, r := i, j; l < r; l, r = l+1, r-1 {
+		out[l], out[r] = out[r], out[l]
+	}
+	return out
+}
+
+func respectsPickupOrder(route []routeStop) bool {
+	toCollect := make(map[string]bool)
+	for _, stop := range route {
+		if stop.kind == StopPickup {
+			toCollect[stop.orderID] = true
+		}
+	}
+	for _, stop := range route {
+		switch stop.kind {
+		case StopPickup:
+			toCollect[stop.orderID] = false
+		case StopDropoff:
+			if toCollect[stop.orderID] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func routeLength(start Point, route []routeStop) float64 {
+	total := 0.0
+	prev := start
+	for _, stop := range route {
+		total += distanceMeters(prev, stop.location)
+		prev = stop.location
+	}
+	return total
+}
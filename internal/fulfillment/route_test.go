@@ -0,0 +1,144 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func stopKeys(route []routeStop) []string {
+	keys := make([]string, len(route))
+	for i, stop := range route {
+		keys[i] = stop.orderID + ":" + stop.kind
+	}
+	return keys
+}
+
+func TestSequenceStopsPicksUpBeforeDropOff(t *testing.T) {
+	// The drop-off for order a is right next to the driver, but its pickup
+	// is across town, so it must not come first.
+	driver := Point{Lat: 40.7527, Lng: -73.9772}
+	stops := []routeStop{
+		{orderID: "a", kind: StopDropoff, location: nextDoor},
+		{orderID: "a", kind: StopPickup, location: brooklyn},
+	}
+
+	route := sequenceStops(driver, stops)
+
+	assert.Equal(t, []string{"a:PICKUP", "a:DROPOFF"}, stopKeys(route))
+}
+
+func TestSequenceStopsBatchesSharedPickup(t *testing.T) {
+	stops := []routeStop{
+		{orderID: "a", kind: StopPickup, location: restaurant},
+		{orderID: "a", kind: StopDropoff, location: brooklyn},
+		{orderID: "b", kind: StopPickup, location: restaurant},
+		{orderID: "b", kind: StopDropoff, location: nearbyHouse},
+	}
+
+	route := sequenceStops(restaurant, stops)
+
+	assert.Equal(t, []string{"a:PICKUP", "b:PICKUP", "b:DROPOFF", "a:DROPOFF"}, stopKeys(route))
+	assert.True(t, respectsPickupOrder(route))
+}
+
+func TestSequenceStopsKeepsPickedUpDropOffs(t *testing.T) {
+	stops := []routeStop{
+		{orderID: "a", kind: StopDropoff, location: brooklyn},
+		{orderID: "b", kind: StopDropoff, location: nextDoor},
+	}
+
+	route := sequenceStops(nearbyHouse, stops)
+
+	assert.Equal(t, []string{"b:DROPOFF", "a:DROPOFF"}, stopKeys(route))
+}
+
+func TestSequenceStopsReturnsWithoutPickup(t *testing.T) {
+	stops := []routeStop{
+		{orderID: "a", kind: StopReturn, location: restaurant},
+		{orderID: "b", kind: StopDropoff, location: nextDoor},
+	}
+
+	route := sequenceStops(nearbyHouse, stops)
+
+	assert.Equal(t, []string{"b:DROPOFF", "a:RETURN"}, stopKeys(route))
+	assert.True(t, respectsPickupOrder(route))
+}
+
+func TestRespectsPickupOrder(t *testing.T) {
+	assert.False(t, respectsPickupOrder([]routeStop{
+		{orderID: "a", kind: StopDropoff},
+		{orderID: "a", kind: StopPickup},
+	}))
+	assert.True(t, respectsPickupOrder([]routeStop{
+		{orderID: "a", kind: StopPickup},
+		{orderID: "a", kind: StopDropoff},
+	}))
+}
+
+func TestPlanRoute(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1`).
+		WithArgs("dp1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "location"}).AddRow("dp1", "BUSY", ewkb(restaurant)))
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE delivery_person_id = \$1 AND status IN \(\$2,\$3,\$4\) ORDER BY created_at`).
+		WithArgs("dp1", "ASSIGNED", "IN_PROGRESS", "RETURNING").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "delivery_person_id", "status", "pickup", "dropoff"}).
+			AddRow("order1", "dp1", "IN_PROGRESS", ewkb(restaurant), ewkb(brooklyn)).
+			AddRow("order2", "dp1", "ASSIGNED", ewkb(restaurant), ewkb(nearbyHouse)))
+	mock.ExpectExec(`DELETE FROM "route_stops" WHERE delivery_person_id = \$1`).
+		WithArgs("dp1").
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO "route_stops" \("delivery_person_id","sequence","order_id","kind","location","leg_meters"\) VALUES \(.*\),\(.*\),\(.*\)`).
+		WithArgs(
+			"dp1", 1, "order2", "PICKUP", sqlmock.AnyArg(), 0.0,
+			"dp1", 2, "order2", "DROPOFF", sqlmock.AnyArg(), sqlmock.AnyArg(),
+			"dp1", 3, "order1", "DROPOFF", sqlmock.AnyArg(), sqlmock.AnyArg(),
+		).
+		WillReturnResult(sqlmock.NewResult(3, 3))
+	mock.ExpectExec(`UPDATE "orders" SET "delivery_eta"=\$1,"pickup_eta"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), "order2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`UPDATE "orders" SET "delivery_eta"=\$1,"updated_at"=\$2 WHERE "order_id" = \$3`).
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), "order1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		return service.planRoute(tx, "dp1")
+	})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetDriverRoute(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectQuery(`SELECT \* FROM "route_stops" WHERE delivery_person_id = \$1 ORDER BY sequence`).
+		WithArgs("dp1").
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "sequence", "order_id", "kind", "location", "leg_meters"}).
+			AddRow("dp1", 1, "order1", "PICKUP", ewkb(restaurant), 150.0).
+			AddRow("dp1", 2, "order1", "DROPOFF", ewkb(nearbyHouse), 850.0))
+
+	resp, err := service.GetDriverRoute(context.Background(), &pb.GetDriverRouteRequest{DeliveryPersonId: "dp1"})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.Stops, 2)
+	assert.Equal(t, "PICKUP", resp.Stops[0].Kind)
+	assert.Equal(t, int32(2), resp.Stops[1].Sequence)
+	assert.Equal(t, nearbyHouse.Lat, resp.Stops[1].Location.Lat)
+	assert.Equal(t, 1000.0, resp.TotalDistanceMeters)
+}
@@ -0,0 +1,110 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"fullfillment-service/internal/logging"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WindowMet   = "MET"
+	WindowEarly = "EARLY"
+	WindowLate  = "LATE"
+)
+
+// dispatchAt works out when a scheduled order has to be offered for it to
+// reach the customer as its window opens: long enough for the offer to be
+// answered, a delivery person to reach the pickup and the trip itself.
+func (s *OrderService) dispatchAt(order *Order) int64 {
+	lead := s.cfg.OfferTimeout + s.cfg.ScheduleLeadTime + s.cfg.StopDwell
+	if order.Pickup != nil && order.Dropoff != nil {
+		lead += s.travelTime(distanceMeters(*order.Pickup, *order.Dropoff), "", order.ZoneID)
+	}
+	return order.WindowStart - int64(lead.Seconds())
+}
+
+// DispatchScheduled offers every scheduled order whose dispatch time has
+// come, most urgent first. It returns the number of orders taken off the schedule.
+func (s *OrderService) DispatchScheduled(ctx context.Context) (int, error) {
+	var orders []Order
+	if err := s.db.WithContext(ctx).
+		Where("status = ? AND dispatch_at <= ?", OrderStatusScheduled, s.now().Unix()).
+		Order(priorityOrder).
+		Order("dispatch_at").
+		Find(&orders).Error; err != nil {
+		return 0, err
+	}
+
+	dispatched := 0
+	for i := range orders {
+		order := orders[i]
+		err := s.transaction(ctx, func(tx *gorm.DB) error {
+			previous := order
+			result := tx.Model(&order).Where("status = ?", OrderStatusScheduled).Updates(map[string]interface{}{
+				"status":            OrderStatusOffered,
+				"status_changed_at": s.now().Unix(),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				// Dispatched by another scheduler already.
+				return nil
+			}
+			dispatched++
+			s.observeStatusChange(tx, &previous, OrderStatusOffered)
+			if err := s.recordOrderEvent(tx, EventOrderStatusChanged, order.OrderID, "", OrderStatusOffered); err != nil {
+				return err
+			}
+
+			offer, err := s.offerNext(tx, &order)
+			if err != nil {
+				return err
+			}
+			if offer == nil {
+				return s.setOrderStatus(tx, &order, OrderStatusUnassigned)
+			}
+			return nil
+		})
+		if err != nil {
+			return dispatched, err
+		}
+	}
+	return dispatched, nil
+}
+
+// RunScheduler calls DispatchScheduled every interval until ctx is
+// cancelled.
+func (s *OrderService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DispatchScheduled(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to dispatch scheduled orders", "error", err)
+			}
+		}
+	}
+}
+
+// windowOutcome says whether an order delivered at deliveredAt made its
+// promised window. Orders without a window have no outcome.
+func windowOutcome(order *Order, deliveredAt int64) string {
+	switch {
+	case order.WindowEnd == 0:
+		return ""
+	case deliveredAt < order.WindowStart:
+		return WindowEarly
+	case deliveredAt > order.WindowEnd:
+		return WindowLate
+	default:
+		return WindowMet
+	}
+}
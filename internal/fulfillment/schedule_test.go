@@ -0,0 +1,146 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWindowOutcome(t *testing.T) {
+	order := &Order{WindowStart: 1000, WindowEnd: 2000}
+
+	assert.Equal(t, WindowEarly, windowOutcome(order, 999))
+	assert.Equal(t, WindowMet, windowOutcome(order, 1000))
+	assert.Equal(t, WindowMet, windowOutcome(order, 2000))
+	assert.Equal(t, WindowLate, windowOutcome(order, 2001))
+	assert.Equal(t, "", windowOutcome(&Order{}, 2001))
+}
+
+func TestAssignOrderScheduled(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Future Window Is Scheduled", func(t *testing.T) {
+		start := now.Add(3 * time.Hour).Unix()
+
+		mock.ExpectBegin()
+		expectZoneLookup(mock, "")
+		mock.ExpectExec(`INSERT INTO "orders"`).
+			WithArgs(orderInsertArgs("order1", "SCHEDULED")...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+			OrderId:             "order1",
+			Pickup:              &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+			Dropoff:             &pb.Location{Lat: nearbyHouse.Lat, Lng: nearbyHouse.Lng},
+			DeliveryWindowStart: start,
+			DeliveryWindowEnd:   start + 1800,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "SCHEDULED", resp.Status)
+		assert.Empty(t, resp.OfferId)
+		assert.Greater(t, resp.DispatchAt, now.Unix())
+		assert.Less(t, resp.DispatchAt, start-int64((15*time.Minute).Seconds()))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Window Ends Before It Starts", func(t *testing.T) {
+		start := now.Add(3 * time.Hour).Unix()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+			OrderId:             "order2",
+			DeliveryWindowStart: start,
+			DeliveryWindowEnd:   start - 60,
+		})
+
+		assert.Equal(t, "FAILED", resp.Status)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Window Already Over", func(t *testing.T) {
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+			OrderId:             "order3",
+			DeliveryWindowStart: now.Add(-time.Hour).Unix(),
+			DeliveryWindowEnd:   now.Add(-30 * time.Minute).Unix(),
+		})
+
+		assert.Equal(t, "FAILED", resp.Status)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestDispatchScheduled(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	cfg := DefaultConfig()
+	cfg.BatchWindow = 0
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE status = \$1 AND dispatch_at <= \$2 ORDER BY CASE priority .* END DESC,dispatch_at`).
+		WithArgs("SCHEDULED", now.Unix()).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status", "pickup", "dropoff", "dispatch_at"}).
+			AddRow("order1", "SCHEDULED", ewkb(restaurant), ewkb(nearbyHouse), now.Unix()-60).
+			AddRow("order2", "SCHEDULED", ewkb(restaurant), ewkb(nearbyHouse), now.Unix()))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE status = \$4 AND "order_id" = \$5`).
+		WithArgs("OFFERED", sqlmock.AnyArg(), sqlmock.AnyArg(), "SCHEDULED", "order1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "order1")
+	expectCandidateQuery(mock, "order1", 1000,
+		sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp1", "AVAILABLE"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOfferEstimate(mock, "dp1", nearbyHouse)
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE status = \$4 AND "order_id" = \$5`).
+		WithArgs("OFFERED", sqlmock.AnyArg(), sqlmock.AnyArg(), "SCHEDULED", "order2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "order2")
+	expectNoCandidates(mock, "order2")
+	mock.ExpectExec(`UPDATE "orders" SET "status"=\$1,"status_changed_at"=\$2,"updated_at"=\$3 WHERE "order_id" = \$4`).
+		WithArgs("UNASSIGNED", sqlmock.AnyArg(), sqlmock.AnyArg(), "order2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectEvent(mock, "order.status_changed", "order2")
+	mock.ExpectCommit()
+
+	dispatched, err := service.DispatchScheduled(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUpdateOrderStatusRejectsScheduledOrder(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE order_id = \$1`).
+		WithArgs("order1", 1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow("order1", "SCHEDULED"))
+	mock.ExpectRollback()
+
+	resp, err := service.UpdateOrderStatus(context.Background(), &pb.UpdateOrderStatusRequest{OrderId: "order1", Status: "IN_PROGRESS"})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,158 @@
+package fulfillment
+
+import (
+	"context"
+	"errors"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// shiftEndedDeclineReason is recorded on offers withdrawn because the
+// delivery person went off duty before answering.
+const shiftEndedDeclineReason = "shift ended"
+
+func (s *OrderService) StartShift(ctx context.Context, req *pb.StartShiftRequest) (*pb.StartShiftResponse, error) {
+	var shift Shift
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		driver, err := lockDeliveryPerson(tx, req.DeliveryPersonId)
+		if err != nil {
+			return err
+		}
+
+		open, err := openShift(tx, driver.DeliveryPersonID)
+		if err != nil {
+			return err
+		}
+		if open != nil {
+			return status.Error(codes.FailedPrecondition, "shift already started")
+		}
+
+		shift = Shift{
+			ShiftID:          newID(),
+			DeliveryPersonID: driver.DeliveryPersonID,
+			StartedAt:        s.now().Unix(),
+			StartLocation:    pointFromProto(req.Location),
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"status": DeliveryPersonAvailable, "last_seen_at": shift.StartedAt}
+		if shift.StartLocation != nil {
+			zoneID, err := zoneAt(tx, *shift.StartLocation)
+			if err != nil {
+				return err
+			}
+			updates["location"] = *shift.StartLocation
+			updates["zone_id"] = zoneID
+		}
+		if err := tx.Model(driver).Updates(updates).Error; err != nil {
+			return err
+		}
+		return refreshDriverStatus(tx, driver.DeliveryPersonID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.StartShiftResponse{ShiftId: shift.ShiftID, StartedAt: shift.StartedAt}, nil
+}
+
+// EndShift takes a delivery person off duty. It is rejected while they still
+// have active orders; offers they have not answered yet are passed on.
+func (s *OrderService) EndShift(ctx context.Context, req *pb.EndShiftRequest) (*pb.EndShiftResponse, error) {
+	var shift *Shift
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		driver, err := lockDeliveryPerson(tx, req.DeliveryPersonId)
+		if err != nil {
+			return err
+		}
+
+		shift, err = openShift(tx, driver.DeliveryPersonID)
+		if err != nil {
+			return err
+		}
+		if shift == nil {
+			return status.Error(codes.FailedPrecondition, "no shift in progress")
+		}
+
+		var active int64
+		if err := tx.Model(&Order{}).
+			Where("delivery_person_id = ? AND status IN ?", driver.DeliveryPersonID, activeOrderStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return status.Errorf(codes.FailedPrecondition, "delivery person still has %d active orders", active)
+		}
+
+		shift.EndedAt = s.now().Unix()
+		shift.EndLocation = pointFromProto(req.Location)
+		shiftUpdates := map[string]interface{}{"ended_at": shift.EndedAt}
+		if shift.EndLocation != nil {
+			shiftUpdates["end_location"] = *shift.EndLocation
+		}
+		if err := tx.Model(shift).Updates(shiftUpdates).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(driver).Update("status", DeliveryPersonOffline).Error; err != nil {
+			return err
+		}
+
+		var pending []Offer
+		if err := tx.Where("delivery_person_id = ? AND status = ?", driver.DeliveryPersonID, OfferPending).
+			Find(&pending).Error; err != nil {
+			return err
+		}
+		for i := range pending {
+			if err := s.closeOffer(tx, &pending[i], OfferDeclined, shiftEndedDeclineReason); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.EndShiftResponse{ShiftId: shift.ShiftID, EndedAt: shift.EndedAt}, nil
+}
+
+func lockDeliveryPerson(tx *gorm.DB, deliveryPersonID string) (*DeliveryPerson, error) {
+	var driver DeliveryPerson
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&driver, "delivery_person_id = ?", deliveryPersonID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "delivery person not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &driver, nil
+}
+
+// openShift returns the delivery person's shift in progress, or nil when they
+// are off duty.
+func openShift(tx *gorm.DB, deliveryPersonID string) (*Shift, error) {
+	var shifts []Shift
+	if err := tx.Where("delivery_person_id = ? AND ended_at = 0", deliveryPersonID).
+		Limit(1).
+		Find(&shifts).Error; err != nil {
+		return nil, err
+	}
+	if len(shifts) == 0 {
+		return nil, nil
+	}
+	return &shifts[0], nil
+}
+
+// onShift limits a delivery_people query to drivers with a shift in
+// progress.
+func onShift(db *gorm.DB) *gorm.DB {
+	return db.Where("EXISTS (SELECT 1 FROM shifts WHERE shifts.delivery_person_id = delivery_people.delivery_person_id AND shifts.ended_at = 0)")
+}
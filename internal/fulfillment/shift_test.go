@@ -0,0 +1,164 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+// expectDriverLock expects deliveryPersonID to be loaded for update and
+// answers with status.
+func expectDriverLock(mock sqlmock.Sqlmock, deliveryPersonID, status string) {
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE delivery_person_id = \$1 ORDER BY .* LIMIT \$2 FOR UPDATE`).
+		WithArgs(deliveryPersonID, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow(deliveryPersonID, status))
+}
+
+// expectOpenShiftLookup expects a lookup of deliveryPersonID's shift in
+// progress and answers with shiftID, or with none when it is empty.
+func expectOpenShiftLookup(mock sqlmock.Sqlmock, deliveryPersonID, shiftID string) {
+	rows := sqlmock.NewRows([]string{"shift_id", "delivery_person_id", "started_at", "ended_at"})
+	if shiftID != "" {
+		rows.AddRow(shiftID, deliveryPersonID, 1699990000, 0)
+	}
+	mock.ExpectQuery(`SELECT \* FROM "shifts" WHERE delivery_person_id = \$1 AND ended_at = 0 LIMIT \$2`).
+		WithArgs(deliveryPersonID, 1).
+		WillReturnRows(rows)
+}
+
+func TestStartShift(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Goes Online At Start Location", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectDriverLock(mock, "dp1", "OFFLINE")
+		expectOpenShiftLookup(mock, "dp1", "")
+		mock.ExpectExec(`INSERT INTO "shifts"`).
+			WithArgs(sqlmock.AnyArg(), "dp1", now.Unix(), 0, "SRID=4326;POINT(-73.9857 40.7484)", nil).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectZoneLookup(mock, "midtown")
+		mock.ExpectExec(`UPDATE "delivery_people" SET "last_seen_at"=\$1,"location"=\$2,"status"=\$3,"zone_id"=\$4 WHERE "delivery_person_id" = \$5`).
+			WithArgs(now.Unix(), sqlmock.AnyArg(), "AVAILABLE", "midtown", "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectDriverStatusRefresh(mock, "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.StartShift(context.Background(), &pb.StartShiftRequest{
+			DeliveryPersonId: "dp1",
+			Location:         &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.ShiftId)
+		assert.Equal(t, now.Unix(), resp.StartedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Shift Already Started", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectDriverLock(mock, "dp1", "AVAILABLE")
+		expectOpenShiftLookup(mock, "dp1", "shift1")
+		mock.ExpectRollback()
+
+		resp, err := service.StartShift(context.Background(), &pb.StartShiftRequest{DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Delivery Person", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT \* FROM "delivery_people"`).
+			WillReturnError(gorm.ErrRecordNotFound)
+		mock.ExpectRollback()
+
+		resp, err := service.StartShift(context.Background(), &pb.StartShiftRequest{DeliveryPersonId: "ghost"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEndShift(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	service := NewService(db, WithClock(func() time.Time { return now }))
+
+	t.Run("Success - Goes Offline And Passes On Pending Offers", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectDriverLock(mock, "dp1", "AVAILABLE")
+		expectOpenShiftLookup(mock, "dp1", "shift1")
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "orders" WHERE delivery_person_id = \$1 AND status IN \(\$2,\$3,\$4\)`).
+			WithArgs("dp1", "ASSIGNED", "IN_PROGRESS", "RETURNING").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectExec(`UPDATE "shifts" SET "ended_at"=\$1 WHERE "shift_id" = \$2`).
+			WithArgs(now.Unix(), "shift1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(`UPDATE "delivery_people" SET "status"=\$1 WHERE "delivery_person_id" = \$2`).
+			WithArgs("OFFLINE", "dp1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectQuery(`SELECT \* FROM "offers" WHERE delivery_person_id = \$1 AND status = \$2`).
+			WithArgs("dp1", "PENDING").
+			WillReturnRows(sqlmock.NewRows(offerColumns).AddRow("offer1", "order1", "dp1", "PENDING", now.Unix()+10))
+		mock.ExpectExec(`UPDATE "offers" SET "decline_reason"=\$1,"responded_at"=\$2,"status"=\$3 WHERE offer_id = \$4 AND status = \$5`).
+			WithArgs("shift ended", now.Unix(), "DECLINED", "offer1", "PENDING").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectOrderLookup(mock, "order1")
+		expectCandidateQuery(mock, "order1", 1000, sqlmock.NewRows([]string{"delivery_person_id", "status"}).AddRow("dp2", "AVAILABLE"))
+		mock.ExpectExec(`INSERT INTO "offers"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.EndShift(context.Background(), &pb.EndShiftRequest{DeliveryPersonId: "dp1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "shift1", resp.ShiftId)
+		assert.Equal(t, now.Unix(), resp.EndedAt)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Active Orders Remain", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectDriverLock(mock, "dp1", "BUSY")
+		expectOpenShiftLookup(mock, "dp1", "shift2")
+		mock.ExpectQuery(`SELECT count\(\*\) FROM "orders"`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectRollback()
+
+		resp, err := service.EndShift(context.Background(), &pb.EndShiftRequest{DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.Contains(t, err.Error(), "2 active orders")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - No Shift In Progress", func(t *testing.T) {
+		mock.ExpectBegin()
+		expectDriverLock(mock, "dp1", "OFFLINE")
+		expectOpenShiftLookup(mock, "dp1", "")
+		mock.ExpectRollback()
+
+		resp, err := service.EndShift(context.Background(), &pb.EndShiftRequest{DeliveryPersonId: "dp1"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
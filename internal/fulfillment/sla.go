@@ -0,0 +1,197 @@
+package fulfillment
+
+import (
+	"context"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	SLAAtRisk   = "AT_RISK"
+	SLABreached = "BREACHED"
+)
+
+// slaRanks orders SLA statuses from healthy to breached. An order's status
+// only ever moves up, so each threshold alerts once.
+var slaRanks = map[string]int{"": 0, SLAAtRisk: 1, SLABreached: 2}
+
+// slaMonitoredStatuses are the order states the SLA clock runs in.
+var slaMonitoredStatuses = []string{OrderStatusOffered, OrderStatusUnassigned, OrderStatusAssigned, OrderStatusInProgress}
+
+type slaKey struct {
+	zoneID   string
+	priority string
+}
+
+func (s *OrderService) SetSlaTarget(ctx context.Context, req *pb.SetSlaTargetRequest) (*pb.SetSlaTargetResponse, error) {
+	if _, ok := priorityRanks[req.Priority]; req.Priority != "" && !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown priority %q", req.Priority)
+	}
+	if req.TargetSeconds <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "target must be positive")
+	}
+
+	target := SLATarget{ZoneID: req.ZoneId, Priority: req.Priority, TargetSeconds: req.TargetSeconds}
+	if err := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "zone_id"}, {Name: "priority"}},
+		DoUpdates: clause.AssignmentColumns([]string{"target_seconds", "updated_at"}),
+	}).Create(&target).Error; err != nil {
+		return nil, err
+	}
+	return &pb.SetSlaTargetResponse{Target: slaTargetToProto(&target)}, nil
+}
+
+func (s *OrderService) ListSlaTargets(ctx context.Context, req *pb.ListSlaTargetsRequest) (*pb.ListSlaTargetsResponse, error) {
+	var targets []SLATarget
+	if err := s.db.WithContext(ctx).Order("zone_id, priority").Find(&targets).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListSlaTargetsResponse{}
+	for i := range targets {
+		resp.Targets = append(resp.Targets, slaTargetToProto(&targets[i]))
+	}
+	return resp, nil
+}
+
+// MonitorSLAs compares every order on the SLA clock against its target and
+// flags those at risk of missing it or past it, raising an alert through the
+// notifier each time an order crosses a threshold. It returns the number of
+// alerts raised.
+func (s *OrderService) MonitorSLAs(ctx context.Context) (int, error) {
+	var stored []SLATarget
+	if err := s.db.WithContext(ctx).Find(&stored).Error; err != nil {
+		return 0, err
+	}
+	targets := make(map[slaKey]time.Duration, len(stored))
+	for _, target := range stored {
+		targets[slaKey{target.ZoneID, target.Priority}] = time.Duration(target.TargetSeconds) * time.Second
+	}
+
+	var orders []Order
+	if err := s.db.WithContext(ctx).
+		Where("status IN ? AND sla_status <> ?", slaMonitoredStatuses, SLABreached).
+		Find(&orders).Error; err != nil {
+		return 0, err
+	}
+
+	now := s.now().Unix()
+	alerts := 0
+	for i := range orders {
+		order := &orders[i]
+		dueAt, start := s.slaDeadline(targets, order)
+		if dueAt == 0 {
+			continue
+		}
+		prev := order.SLAStatus
+		next := s.slaStatus(order, start, dueAt, now)
+		if slaRanks[next] < slaRanks[prev] {
+			next = prev
+		}
+		if next == prev && dueAt == order.SLADueAt {
+			continue
+		}
+
+		result := s.db.WithContext(ctx).Model(order).
+			Where("sla_status = ?", prev).
+			Updates(map[string]interface{}{"sla_status": next, "sla_due_at": dueAt})
+		if result.Error != nil {
+			return alerts, result.Error
+		}
+		if result.RowsAffected == 0 || next == prev {
+			continue
+		}
+
+		alert := Alert{
+			OrderID:     order.OrderID,
+			ZoneID:      order.ZoneID,
+			Priority:    order.Priority,
+			SLAStatus:   next,
+			DueAt:       dueAt,
+			DeliveryETA: order.DeliveryETA,
+			RaisedAt:    now,
+		}
+		if err := s.notifier.Notify(ctx, alert); err != nil {
+			logging.FromContext(ctx).Error("failed to send SLA alert", "order_id", order.OrderID, "error", err)
+		}
+		alerts++
+	}
+	return alerts, nil
+}
+
+// RunSLAMonitor calls MonitorSLAs every interval until ctx is cancelled.
+func (s *OrderService) RunSLAMonitor(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.MonitorSLAs(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to monitor SLAs", "error", err)
+			}
+		}
+	}
+}
+
+// slaDeadline returns when the order is due and when its SLA clock started.
+// Orders with a promised window are due at its end, timed from dispatch;
+// others are due their target after being placed. The target is the most
+// specific one stored for the order's zone and priority, falling back to
+// the configured default for its priority. A zero deadline means the order
+// has no SLA.
+func (s *OrderService) slaDeadline(targets map[slaKey]time.Duration, order *Order) (dueAt, start int64) {
+	if order.WindowEnd != 0 {
+		return order.WindowEnd, order.DispatchAt
+	}
+
+	priority := order.Priority
+	if priority == "" {
+		priority = PriorityStandard
+	}
+	var target time.Duration
+	ok := false
+	for _, key := range []slaKey{{order.ZoneID, priority}, {order.ZoneID, ""}, {"", priority}, {"", ""}} {
+		if target, ok = targets[key]; ok {
+			break
+		}
+	}
+	if !ok {
+		target = s.cfg.SLATargets[priority]
+	}
+	if target <= 0 {
+		return 0, 0
+	}
+	return order.CreatedAt + int64(target.Seconds()), order.CreatedAt
+}
+
+// slaStatus is BREACHED once an order is overdue and AT_RISK when it is
+// expected to arrive late or has used up SLAAtRiskRatio of its time.
+func (s *OrderService) slaStatus(order *Order, start, dueAt, now int64) string {
+	switch {
+	case now >= dueAt:
+		return SLABreached
+	case order.DeliveryETA > dueAt:
+		return SLAAtRisk
+	case s.cfg.SLAAtRiskRatio > 0 && float64(now-start) >= s.cfg.SLAAtRiskRatio*float64(dueAt-start):
+		return SLAAtRisk
+	default:
+		return ""
+	}
+}
+
+func slaTargetToProto(target *SLATarget) *pb.SlaTarget {
+	return &pb.SlaTarget{
+		ZoneId:        target.ZoneID,
+		Priority:      target.Priority,
+		TargetSeconds: target.TargetSeconds,
+	}
+}
@@ -0,0 +1,129 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSLADeadline(t *testing.T) {
+	service := NewService(nil)
+	targets := map[slaKey]time.Duration{
+		{"midtown", PriorityExpress}: 20 * time.Minute,
+		{"midtown", ""}:              50 * time.Minute,
+	}
+
+	due, start := service.slaDeadline(targets, &Order{ZoneID: "midtown", Priority: PriorityExpress, CreatedAt: 1000})
+	assert.Equal(t, int64(1000+20*60), due)
+	assert.Equal(t, int64(1000), start)
+
+	due, _ = service.slaDeadline(targets, &Order{ZoneID: "midtown", Priority: PriorityVIP, CreatedAt: 1000})
+	assert.Equal(t, int64(1000+50*60), due)
+
+	due, _ = service.slaDeadline(targets, &Order{ZoneID: "brooklyn", Priority: PriorityMedical, CreatedAt: 1000})
+	assert.Equal(t, int64(1000+20*60), due)
+
+	due, _ = service.slaDeadline(targets, &Order{CreatedAt: 1000})
+	assert.Equal(t, int64(1000+60*60), due)
+
+	due, start = service.slaDeadline(targets, &Order{CreatedAt: 1000, DispatchAt: 5000, WindowEnd: 9000})
+	assert.Equal(t, int64(9000), due)
+	assert.Equal(t, int64(5000), start)
+}
+
+func TestSLAStatus(t *testing.T) {
+	service := NewService(nil)
+
+	assert.Equal(t, "", service.slaStatus(&Order{}, 0, 1000, 500))
+	assert.Equal(t, SLAAtRisk, service.slaStatus(&Order{}, 0, 1000, 800))
+	assert.Equal(t, SLAAtRisk, service.slaStatus(&Order{DeliveryETA: 1100}, 0, 1000, 100))
+	assert.Equal(t, SLABreached, service.slaStatus(&Order{}, 0, 1000, 1000))
+}
+
+func TestMonitorSLAs(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	var alerts []Alert
+	service := NewService(db,
+		WithClock(func() time.Time { return now }),
+		WithNotifier(NotifierFunc(func(ctx context.Context, alert Alert) error {
+			alerts = append(alerts, alert)
+			return nil
+		})))
+
+	placed := now.Add(-40 * time.Minute).Unix()
+	mock.ExpectQuery(`SELECT \* FROM "sla_targets"`).
+		WillReturnRows(sqlmock.NewRows([]string{"zone_id", "priority", "target_seconds"}).
+			AddRow("midtown", "EXPRESS", 1800))
+	mock.ExpectQuery(`SELECT \* FROM "orders" WHERE status IN \(\$1,\$2,\$3,\$4\) AND sla_status <> \$5`).
+		WithArgs("OFFERED", "UNASSIGNED", "ASSIGNED", "IN_PROGRESS", "BREACHED").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "zone_id", "priority", "status", "sla_status", "sla_due_at", "delivery_eta", "created_at"}).
+			AddRow("late", "midtown", "EXPRESS", "IN_PROGRESS", "AT_RISK", placed+1800, 0, placed).
+			AddRow("fine", "midtown", "STANDARD", "ASSIGNED", "", placed+3600, 0, placed).
+			AddRow("slow", "midtown", "STANDARD", "ASSIGNED", "", placed+3600, now.Unix()+25*60, placed))
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "orders" SET "sla_due_at"=\$1,"sla_status"=\$2,"updated_at"=\$3 WHERE sla_status = \$4 AND "order_id" = \$5`).
+		WithArgs(placed+1800, "BREACHED", sqlmock.AnyArg(), "AT_RISK", "late").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "orders" SET "sla_due_at"=\$1,"sla_status"=\$2,"updated_at"=\$3 WHERE sla_status = \$4 AND "order_id" = \$5`).
+		WithArgs(placed+3600, "AT_RISK", sqlmock.AnyArg(), "", "slow").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	raised, err := service.MonitorSLAs(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, raised)
+	if assert.Len(t, alerts, 2) {
+		assert.Equal(t, "late", alerts[0].OrderID)
+		assert.Equal(t, SLABreached, alerts[0].SLAStatus)
+		assert.Equal(t, "slow", alerts[1].OrderID)
+		assert.Equal(t, SLAAtRisk, alerts[1].SLAStatus)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSetSlaTarget(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Upserts Target", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "sla_targets" .* ON CONFLICT \("zone_id","priority"\) DO UPDATE SET "target_seconds"="excluded"\."target_seconds","updated_at"="excluded"\."updated_at"`).
+			WithArgs("midtown", "EXPRESS", int64(1200), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.SetSlaTarget(context.Background(), &pb.SetSlaTargetRequest{ZoneId: "midtown", Priority: "EXPRESS", TargetSeconds: 1200})
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1200), resp.Target.TargetSeconds)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Priority", func(t *testing.T) {
+		resp, err := service.SetSlaTarget(context.Background(), &pb.SetSlaTargetRequest{Priority: "URGENT", TargetSeconds: 1200})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Non-Positive Target", func(t *testing.T) {
+		resp, err := service.SetSlaTarget(context.Background(), &pb.SetSlaTargetRequest{ZoneId: "midtown"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
@@ -0,0 +1,64 @@
+package fulfillment
+
+import "gorm.io/gorm"
+
+const (
+	VehicleBike    = "BIKE"
+	VehicleScooter = "SCOOTER"
+	VehicleCar     = "CAR"
+	VehicleVan     = "VAN"
+
+	PackageSmall  = "SMALL"
+	PackageMedium = "MEDIUM"
+	PackageLarge  = "LARGE"
+	PackageXLarge = "XLARGE"
+)
+
+// packageSizes ranks package sizes from smallest to largest. Orders without
+// a size are treated as small.
+var packageSizes = map[string]int{
+	"":            0,
+	PackageSmall:  0,
+	PackageMedium: 1,
+	PackageLarge:  2,
+	PackageXLarge: 3,
+}
+
+// vehicleSpec describes the largest single package a vehicle type can carry.
+// A delivery person's combined load is limited separately by their own
+// MaxWeightKg and MaxVolumeLiters.
+type vehicleSpec struct {
+	vehicleType     string
+	maxPackageSize  string
+	maxItemWeightKg float64
+}
+
+var vehicleSpecs = []vehicleSpec{
+	{vehicleType: VehicleBike, maxPackageSize: PackageSmall, maxItemWeightKg: 5},
+	{vehicleType: VehicleScooter, maxPackageSize: PackageMedium, maxItemWeightKg: 15},
+	{vehicleType: VehicleCar, maxPackageSize: PackageLarge, maxItemWeightKg: 50},
+	{vehicleType: VehicleVan, maxPackageSize: PackageXLarge, maxItemWeightKg: 500},
+}
+
+// vehiclesFor lists the vehicle types able to carry order, smallest first.
+func vehiclesFor(order *Order) []string {
+	var vehicles []string
+	for _, spec := range vehicleSpecs {
+		if packageSizes[order.PackageSize] > packageSizes[spec.maxPackageSize] {
+			continue
+		}
+		if order.WeightKg > spec.maxItemWeightKg {
+			continue
+		}
+		vehicles = append(vehicles, spec.vehicleType)
+	}
+	return vehicles
+}
+
+// withSuitableVehicle limits a delivery_people query to drivers whose
+// vehicle can carry the order.
+func withSuitableVehicle(order *Order) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("delivery_people.vehicle_type IN ?", vehiclesFor(order))
+	}
+}
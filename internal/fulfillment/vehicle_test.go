@@ -0,0 +1,98 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+)
+
+func TestVehiclesFor(t *testing.T) {
+	assert.Equal(t, []string{"BIKE", "SCOOTER", "CAR", "VAN"}, vehiclesFor(&Order{WeightKg: 2}))
+	assert.Equal(t, []string{"SCOOTER", "CAR", "VAN"}, vehiclesFor(&Order{PackageSize: PackageMedium}))
+	assert.Equal(t, []string{"CAR", "VAN"}, vehiclesFor(&Order{PackageSize: PackageSmall, WeightKg: 20}))
+	assert.Equal(t, []string{"VAN"}, vehiclesFor(&Order{PackageSize: PackageXLarge, WeightKg: 40}))
+	assert.Empty(t, vehiclesFor(&Order{PackageSize: PackageXLarge, WeightKg: 800}))
+}
+
+func TestWithSuitableVehicle(t *testing.T) {
+	db, _, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	var candidates []DeliveryPerson
+	stmt := db.Session(&gorm.Session{DryRun: true}).
+		Model(&DeliveryPerson{}).
+		Scopes(withSuitableVehicle(&Order{PackageSize: PackageLarge})).
+		Find(&candidates).Statement
+
+	assert.Contains(t, stmt.SQL.String(), "delivery_people.vehicle_type IN ($1,$2)")
+	assert.Equal(t, []interface{}{"CAR", "VAN"}, stmt.Vars)
+}
+
+func TestTravelTimeUsesVehicleSpeed(t *testing.T) {
+	service := NewService(nil)
+
+	bike := service.travelTime(3000, VehicleBike, "")
+	car := service.travelTime(3000, VehicleCar, "")
+	unknown := service.travelTime(3000, "", "")
+
+	assert.Greater(t, bike, car)
+	assert.Equal(t, service.travelTime(3000, "HOVERCRAFT", ""), unknown)
+}
+
+func TestAssignOrderRejectsUncarriableOrder(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Failure - Unknown Package Size", func(t *testing.T) {
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order1", PackageSize: "HUGE"})
+
+		assert.Equal(t, "FAILED", resp.Status)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Too Heavy For Any Vehicle", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order2", PackageSize: PackageXLarge, WeightKg: 900})
+
+		assert.Equal(t, "FAILED", resp.Status)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAssignOrderOnlyConsidersSuitableVehicles(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	cfg := DefaultConfig()
+	cfg.BatchWindow = 0
+	service := NewService(db, WithConfig(cfg))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE .* AND delivery_people\.vehicle_type IN \(\$6\) AND`).
+		WithArgs("AVAILABLE", "order1", "PENDING", sqlmock.AnyArg(), 1000.0, "VAN",
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", 120.0,
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", 0.0, sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "vehicle_type"}).AddRow("dp1", "AVAILABLE", "VAN"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{OrderId: "order1", PackageSize: PackageXLarge, WeightKg: 120})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dp1", resp.DeliveryPersonId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,443 @@
+package fulfillment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"fullfillment-service/internal/logging"
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	WebhookPending   = "PENDING"
+	WebhookDelivered = "DELIVERED"
+	WebhookFailed    = "FAILED"
+
+	// Headers sent with every webhook. The signature is the hex HMAC-SHA256
+	// of the timestamp, a dot and the body, keyed with the subscription's
+	// secret; see SignWebhook.
+	WebhookEventIDHeader   = "X-Fulfillment-Event-Id"
+	WebhookEventTypeHeader = "X-Fulfillment-Event-Type"
+	WebhookTimestampHeader = "X-Fulfillment-Timestamp"
+	WebhookSignatureHeader = "X-Fulfillment-Signature"
+)
+
+// webhookEventTypes are the events partners can subscribe to.
+var webhookEventTypes = []string{EventOrderAssigned, EventOrderStatusChanged}
+
+func (s *OrderService) CreateWebhookSubscription(ctx context.Context, req *pb.CreateWebhookSubscriptionRequest) (*pb.CreateWebhookSubscriptionResponse, error) {
+	if err := s.validateWebhookURL(req.Url); err != nil {
+		return nil, err
+	}
+	for _, eventType := range req.EventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown event type %q", eventType)
+		}
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		secret = newID() + newID()
+	}
+	subscription := WebhookSubscription{
+		SubscriptionID: newID(),
+		URL:            req.Url,
+		EventTypes:     strings.Join(req.EventTypes, ","),
+		Secret:         secret,
+		Active:         true,
+	}
+	if err := s.db.WithContext(ctx).Create(&subscription).Error; err != nil {
+		return nil, err
+	}
+	return &pb.CreateWebhookSubscriptionResponse{
+		Subscription: webhookSubscriptionToProto(&subscription),
+		Secret:       secret,
+	}, nil
+}
+
+func (s *OrderService) ListWebhookSubscriptions(ctx context.Context, req *pb.ListWebhookSubscriptionsRequest) (*pb.ListWebhookSubscriptionsResponse, error) {
+	var subscriptions []WebhookSubscription
+	if err := s.db.WithContext(ctx).Order("created_at").Find(&subscriptions).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWebhookSubscriptionsResponse{}
+	for i := range subscriptions {
+		resp.Subscriptions = append(resp.Subscriptions, webhookSubscriptionToProto(&subscriptions[i]))
+	}
+	return resp, nil
+}
+
+func (s *OrderService) DeleteWebhookSubscription(ctx context.Context, req *pb.DeleteWebhookSubscriptionRequest) (*pb.DeleteWebhookSubscriptionResponse, error) {
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Delete(&WebhookSubscription{SubscriptionID: req.SubscriptionId})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "webhook subscription not found")
+		}
+		return tx.Where("subscription_id = ?", req.SubscriptionId).Delete(&WebhookDelivery{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteWebhookSubscriptionResponse{Status: "DELETED"}, nil
+}
+
+// EnableWebhookSubscription turns a subscription back on after it was
+// disabled for failing too often. Deliveries still pending are retried.
+func (s *OrderService) EnableWebhookSubscription(ctx context.Context, req *pb.EnableWebhookSubscriptionRequest) (*pb.EnableWebhookSubscriptionResponse, error) {
+	var subscription WebhookSubscription
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&subscription, "subscription_id = ?", req.SubscriptionId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return status.Error(codes.NotFound, "webhook subscription not found")
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Model(&subscription).Updates(map[string]interface{}{
+			"active":               true,
+			"consecutive_failures": 0,
+			"disabled_at":          0,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.EnableWebhookSubscriptionResponse{Subscription: webhookSubscriptionToProto(&subscription)}, nil
+}
+
+func (s *OrderService) ListWebhookDeliveries(ctx context.Context, req *pb.ListWebhookDeliveriesRequest) (*pb.ListWebhookDeliveriesResponse, error) {
+	limit := int(req.Limit)
+	if limit <= 0 || limit > 100 {
+		limit = 100
+	}
+
+	var deliveries []WebhookDelivery
+	if err := s.db.WithContext(ctx).
+		Where("subscription_id = ?", req.SubscriptionId).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListWebhookDeliveriesResponse{}
+	for _, delivery := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, &pb.WebhookDelivery{
+			DeliveryId:     delivery.DeliveryID,
+			SubscriptionId: delivery.SubscriptionID,
+			EventId:        delivery.EventID,
+			EventType:      delivery.EventType,
+			Status:         delivery.Status,
+			Attempts:       int32(delivery.Attempts),
+			LastStatusCode: int32(delivery.LastStatusCode),
+			LastError:      delivery.LastError,
+			CreatedAt:      delivery.CreatedAt,
+			DeliveredAt:    delivery.DeliveredAt,
+		})
+	}
+	return resp, nil
+}
+
+// enqueueWebhooks queues an outbox event for every subscription that wants
+// it. Queuing the same event twice is a no-op, so a relay pass that is
+// retried does not deliver twice.
+func (s *OrderService) enqueueWebhooks(tx *gorm.DB, event *OutboxEvent, subscriptions []WebhookSubscription) error {
+	var deliveries []WebhookDelivery
+	for _, subscription := range subscriptions {
+		if !subscription.wants(event.EventType) {
+			continue
+		}
+		deliveries = append(deliveries, WebhookDelivery{
+			DeliveryID:     newID(),
+			SubscriptionID: subscription.SubscriptionID,
+			EventID:        event.EventID,
+			EventType:      event.EventType,
+			Payload:        event.Payload,
+			Status:         WebhookPending,
+		})
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&deliveries).Error
+}
+
+func (w *WebhookSubscription) wants(eventType string) bool {
+	if !slices.Contains(webhookEventTypes, eventType) {
+		return false
+	}
+	return w.EventTypes == "" || slices.Contains(strings.Split(w.EventTypes, ","), eventType)
+}
+
+// DeliverWebhooks sends pending webhooks that are due, oldest first. Failed
+// sends are retried with exponential backoff until WebhookMaxAttempts, and
+// a subscription that fails WebhookDisableAfter times in a row is disabled.
+// It returns the number of webhooks delivered.
+func (s *OrderService) DeliverWebhooks(ctx context.Context) (int, error) {
+	var deliveries []WebhookDelivery
+	if err := s.db.WithContext(ctx).
+		Select("webhook_deliveries.*").
+		Joins("JOIN webhook_subscriptions ON webhook_subscriptions.subscription_id = webhook_deliveries.subscription_id").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", WebhookPending, s.now().Unix()).
+		Where("webhook_subscriptions.active = ?", true).
+		Order("webhook_deliveries.created_at").
+		Limit(s.cfg.WebhookBatchSize).
+		Find(&deliveries).Error; err != nil {
+		return 0, err
+	}
+	if len(deliveries) == 0 {
+		return 0, nil
+	}
+
+	var ids []string
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.SubscriptionID)
+	}
+	var subscriptions []WebhookSubscription
+	if err := s.db.WithContext(ctx).Where("subscription_id IN ?", ids).Find(&subscriptions).Error; err != nil {
+		return 0, err
+	}
+	bySubscription := make(map[string]*WebhookSubscription, len(subscriptions))
+	for i := range subscriptions {
+		bySubscription[subscriptions[i].SubscriptionID] = &subscriptions[i]
+	}
+
+	client := s.webhookClient()
+	defer client.CloseIdleConnections()
+	delivered := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription := bySubscription[delivery.SubscriptionID]
+		if subscription == nil || !subscription.Active {
+			continue
+		}
+
+		code, err := s.sendWebhook(ctx, client, subscription, delivery)
+		if err := s.recordWebhookAttempt(ctx, subscription, delivery, code, err); err != nil {
+			return delivered, err
+		}
+		if err == nil {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+func (s *OrderService) sendWebhook(ctx context.Context, client *http.Client, subscription *WebhookSubscription, delivery *WebhookDelivery) (int, error) {
+	body := []byte(delivery.Payload)
+	timestamp := s.now().Unix()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookEventIDHeader, delivery.EventID)
+	req.Header.Set(WebhookEventTypeHeader, delivery.EventType)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(subscription.Secret, timestamp, body))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordWebhookAttempt stores the outcome of one send in the delivery log
+// and keeps the subscription's run of failures up to date. Failures are
+// counted in the database, so dispatchers failing at the same time do not
+// lose each other's.
+func (s *OrderService) recordWebhookAttempt(ctx context.Context, subscription *WebhookSubscription, delivery *WebhookDelivery, code int, sendErr error) error {
+	now := s.now()
+	delivery.Attempts++
+	updates := map[string]interface{}{
+		"attempts":         delivery.Attempts,
+		"last_status_code": code,
+		"last_error":       "",
+	}
+	if sendErr == nil {
+		updates["status"] = WebhookDelivered
+		updates["delivered_at"] = now.Unix()
+	} else {
+		updates["last_error"] = sendErr.Error()
+		if delivery.Attempts >= s.cfg.WebhookMaxAttempts {
+			updates["status"] = WebhookFailed
+		} else {
+			updates["next_attempt_at"] = now.Add(backoff(s.cfg.WebhookRetryBackoff, s.cfg.WebhookMaxBackoff, delivery.Attempts)).Unix()
+		}
+	}
+
+	return s.transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Model(delivery).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if sendErr == nil {
+			if subscription.ConsecutiveFailures == 0 {
+				return nil
+			}
+			subscription.ConsecutiveFailures = 0
+			return tx.Model(subscription).Update("consecutive_failures", 0).Error
+		}
+
+		if err := tx.Model(subscription).
+			Clauses(clause.Returning{Columns: []clause.Column{{Name: "consecutive_failures"}}}).
+			Update("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error; err != nil {
+			return err
+		}
+		if s.cfg.WebhookDisableAfter <= 0 || subscription.ConsecutiveFailures < s.cfg.WebhookDisableAfter {
+			return nil
+		}
+		result := tx.Model(subscription).Where("active").Updates(map[string]interface{}{
+			"active":      false,
+			"disabled_at": now.Unix(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		subscription.Active = false
+		if result.RowsAffected > 0 {
+			logging.FromContext(ctx).Warn("disabling webhook subscription",
+				"subscription_id", subscription.SubscriptionID, "consecutive_failures", subscription.ConsecutiveFailures)
+		}
+		return nil
+	})
+}
+
+// RunWebhookDispatcher calls DeliverWebhooks every interval until ctx is
+// cancelled.
+func (s *OrderService) RunWebhookDispatcher(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.DeliverWebhooks(ctx); err != nil {
+				logging.FromContext(ctx).Error("failed to deliver webhooks", "error", err)
+			}
+		}
+	}
+}
+
+// SignWebhook computes the signature header value for a webhook body sent
+// at timestamp. Receivers recompute it with their secret to check the
+// webhook came from us and was not altered.
+func SignWebhook(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateWebhookURL accepts https URLs on public hosts only, so that a
+// subscription cannot point the dispatcher at our own network.
+// WebhookAllowInsecure lifts both restrictions.
+func (s *OrderService) validateWebhookURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Hostname() == "" {
+		return status.Errorf(codes.InvalidArgument, "invalid webhook URL %q", raw)
+	}
+	if s.cfg.WebhookAllowInsecure {
+		return nil
+	}
+	if u.Scheme != "https" {
+		return status.Errorf(codes.InvalidArgument, "webhook URL %q must use https", raw)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return status.Errorf(codes.InvalidArgument, "webhook URL %q points at an internal address", raw)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !publicAddr(addr) {
+		return status.Errorf(codes.InvalidArgument, "webhook URL %q points at an internal address", raw)
+	}
+	return nil
+}
+
+// webhookClient sends webhooks. Unless WebhookAllowInsecure is set it
+// refuses to connect to addresses that are not public, which also covers
+// hosts that resolve to one, and it does not follow redirects.
+func (s *OrderService) webhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: s.cfg.WebhookTimeout}
+	if !s.cfg.WebhookAllowInsecure {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !publicAddr(addrPort.Addr()) {
+				return fmt.Errorf("webhook address %s is not public", addrPort.Addr())
+			}
+			return nil
+		}
+	}
+	return &http.Client{
+		Timeout: s.cfg.WebhookTimeout,
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			ForceAttemptHTTP2:   true,
+			TLSHandshakeTimeout: 10 * time.Second,
+			MaxIdleConns:        100,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// publicAddr reports whether addr is reachable on the internet, rather than
+// loopback, private, link-local or otherwise special.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+func webhookSubscriptionToProto(subscription *WebhookSubscription) *pb.WebhookSubscription {
+	var eventTypes []string
+	if subscription.EventTypes != "" {
+		eventTypes = strings.Split(subscription.EventTypes, ",")
+	}
+	return &pb.WebhookSubscription{
+		SubscriptionId:      subscription.SubscriptionID,
+		Url:                 subscription.URL,
+		EventTypes:          eventTypes,
+		Active:              subscription.Active,
+		ConsecutiveFailures: int32(subscription.ConsecutiveFailures),
+		DisabledAt:          subscription.DisabledAt,
+	}
+}
@@ -0,0 +1,192 @@
+package fulfillment
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateWebhookSubscription(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Secret Is Generated", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "webhook_subscriptions"`).
+			WithArgs(sqlmock.AnyArg(), "https://partner.example/hooks", "order.status_changed", sqlmock.AnyArg(), true, 0, 0, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		resp, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{
+			Url:        "https://partner.example/hooks",
+			EventTypes: []string{"order.status_changed"},
+		})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.Secret)
+		assert.True(t, resp.Subscription.Active)
+		assert.Equal(t, []string{"order.status_changed"}, resp.Subscription.EventTypes)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Invalid URL", func(t *testing.T) {
+		resp, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{Url: "partner.example/hooks"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Plain HTTP", func(t *testing.T) {
+		resp, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{Url: "http://partner.example/hooks"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Failure - Internal Address", func(t *testing.T) {
+		for _, url := range []string{
+			"https://localhost/hooks",
+			"https://127.0.0.1:8443/hooks",
+			"https://10.0.0.5/hooks",
+			"https://192.168.1.1/hooks",
+			"https://169.254.169.254/latest/meta-data",
+			"https://[::1]/hooks",
+			"https://[fe80::1]/hooks",
+			"https://0.0.0.0/hooks",
+		} {
+			resp, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{Url: url})
+
+			assert.Nil(t, resp, url)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), url)
+		}
+	})
+
+	t.Run("Success - Insecure URLs Allowed", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.WebhookAllowInsecure = true
+		service := NewService(db, WithConfig(cfg))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "webhook_subscriptions"`).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		_, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{Url: "http://127.0.0.1:8080/hooks"})
+
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Event Type", func(t *testing.T) {
+		resp, err := service.CreateWebhookSubscription(context.Background(), &pb.CreateWebhookSubscriptionRequest{
+			Url:        "https://partner.example/hooks",
+			EventTypes: []string{"driver.location_updated"},
+		})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	payload := `{"eventId":"e1","orderStatusChanged":{"orderId":"order1","status":"DELIVERED"}}`
+
+	var received *http.Request
+	var body []byte
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer partner.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer broken.Close()
+
+	cfg := DefaultConfig()
+	cfg.WebhookAllowInsecure = true
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	mock.ExpectQuery(`SELECT webhook_deliveries\.\* FROM "webhook_deliveries" JOIN webhook_subscriptions .* WHERE \(webhook_deliveries\.status = \$1 AND webhook_deliveries\.next_attempt_at <= \$2\) AND webhook_subscriptions\.active = \$3 ORDER BY webhook_deliveries\.created_at LIMIT \$4`).
+		WithArgs("PENDING", now.Unix(), true, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts"}).
+			AddRow("d1", "sub1", "e1", EventOrderStatusChanged, payload, "PENDING", 0).
+			AddRow("d2", "sub2", "e1", EventOrderStatusChanged, payload, "PENDING", 2))
+	mock.ExpectQuery(`SELECT \* FROM "webhook_subscriptions" WHERE subscription_id IN \(\$1,\$2\)`).
+		WithArgs("sub1", "sub2").
+		WillReturnRows(sqlmock.NewRows([]string{"subscription_id", "url", "secret", "active", "consecutive_failures"}).
+			AddRow("sub1", partner.URL, "s3cret", true, 0).
+			AddRow("sub2", broken.URL, "other", true, 19))
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "webhook_deliveries" SET "attempts"=\$1,"delivered_at"=\$2,"last_error"=\$3,"last_status_code"=\$4,"status"=\$5 WHERE "delivery_id" = \$6`).
+		WithArgs(1, now.Unix(), "", 200, "DELIVERED", "d1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE "webhook_deliveries" SET "attempts"=\$1,"last_error"=\$2,"last_status_code"=\$3,"next_attempt_at"=\$4 WHERE "delivery_id" = \$5`).
+		WithArgs(3, "unexpected status 500 Internal Server Error", 500, now.Add(2*time.Minute).Unix(), "d2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`UPDATE "webhook_subscriptions" SET "consecutive_failures"=consecutive_failures \+ 1,"updated_at"=\$1 WHERE "subscription_id" = \$2 RETURNING "consecutive_failures"`).
+		WithArgs(sqlmock.AnyArg(), "sub2").
+		WillReturnRows(sqlmock.NewRows([]string{"consecutive_failures"}).AddRow(20))
+	mock.ExpectExec(`UPDATE "webhook_subscriptions" SET "active"=\$1,"disabled_at"=\$2,"updated_at"=\$3 WHERE active AND "subscription_id" = \$4`).
+		WithArgs(false, now.Unix(), sqlmock.AnyArg(), "sub2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	delivered, err := service.DeliverWebhooks(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	assert.Equal(t, payload, string(body))
+	assert.Equal(t, "e1", received.Header.Get(WebhookEventIDHeader))
+	assert.Equal(t, EventOrderStatusChanged, received.Header.Get(WebhookEventTypeHeader))
+	assert.Equal(t, strconv.FormatInt(now.Unix(), 10), received.Header.Get(WebhookTimestampHeader))
+	assert.Equal(t, SignWebhook("s3cret", now.Unix(), body), received.Header.Get(WebhookSignatureHeader))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestWebhookClientRefusesInternalAddresses(t *testing.T) {
+	partner := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer partner.Close()
+
+	_, err := NewService(nil).webhookClient().Get(partner.URL)
+	assert.ErrorContains(t, err, "webhook address 127.0.0.1 is not public")
+
+	cfg := DefaultConfig()
+	cfg.WebhookAllowInsecure = true
+	resp, err := NewService(nil, WithConfig(cfg)).webhookClient().Get(partner.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+}
+
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"eventId":"e1"}`)
+
+	signature := SignWebhook("s3cret", 1700000000, body)
+
+	assert.Equal(t, "sha256=", signature[:7])
+	assert.Len(t, signature, 7+64)
+	assert.Equal(t, signature, SignWebhook("s3cret", 1700000000, body))
+	assert.NotEqual(t, signature, SignWebhook("other", 1700000000, body))
+	assert.NotEqual(t, signature, SignWebhook("s3cret", 1700000001, body))
+}
@@ -0,0 +1,210 @@
+package fulfillment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	pb "fullfillment-service/proto"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// zoneColumns reads the boundary back as GeoJSON rather than PostGIS' binary
+// format.
+const zoneColumns = "zone_id, name, ST_AsGeoJSON(boundary) AS boundary, created_at, updated_at"
+
+// GeoJSON is a GeoJSON geometry stored in a PostGIS geography column.
+type GeoJSON string
+
+func (g GeoJSON) GormValue(ctx context.Context, db *gorm.DB) clause.Expr {
+	return clause.Expr{SQL: "ST_GeomFromGeoJSON(?)::geography", Vars: []interface{}{string(g)}}
+}
+
+func (s *OrderService) CreateZone(ctx context.Context, req *pb.CreateZoneRequest) (*pb.CreateZoneResponse, error) {
+	if err := validateZone(req.Name, req.Geojson); err != nil {
+		return nil, err
+	}
+
+	zone := Zone{ZoneID: newID(), Name: req.Name, Boundary: GeoJSON(req.Geojson)}
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Create(&zone).Error; err != nil {
+			return err
+		}
+		return rezone(tx, zone.ZoneID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.CreateZoneResponse{Zone: zoneToProto(&zone)}, nil
+}
+
+func (s *OrderService) GetZone(ctx context.Context, req *pb.GetZoneRequest) (*pb.GetZoneResponse, error) {
+	var zone Zone
+	err := s.db.WithContext(ctx).Select(zoneColumns).First(&zone, "zone_id = ?", req.ZoneId).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "zone not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.GetZoneResponse{Zone: zoneToProto(&zone)}, nil
+}
+
+func (s *OrderService) ListZones(ctx context.Context, req *pb.ListZonesRequest) (*pb.ListZonesResponse, error) {
+	var zones []Zone
+	if err := s.db.WithContext(ctx).Select(zoneColumns).Order("name").Find(&zones).Error; err != nil {
+		return nil, err
+	}
+
+	resp := &pb.ListZonesResponse{}
+	for i := range zones {
+		resp.Zones = append(resp.Zones, zoneToProto(&zones[i]))
+	}
+	return resp, nil
+}
+
+func (s *OrderService) UpdateZone(ctx context.Context, req *pb.UpdateZoneRequest) (*pb.UpdateZoneResponse, error) {
+	if err := validateZone(req.Name, req.Geojson); err != nil {
+		return nil, err
+	}
+
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Model(&Zone{ZoneID: req.ZoneId}).Updates(map[string]interface{}{
+			"name":     req.Name,
+			"boundary": GeoJSON(req.Geojson),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "zone not found")
+		}
+		return rezone(tx, req.ZoneId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.UpdateZoneResponse{Zone: &pb.Zone{ZoneId: req.ZoneId, Name: req.Name, Geojson: req.Geojson}}, nil
+}
+
+func (s *OrderService) DeleteZone(ctx context.Context, req *pb.DeleteZoneRequest) (*pb.DeleteZoneResponse, error) {
+	err := s.transaction(ctx, func(tx *gorm.DB) error {
+		result := tx.Delete(&Zone{ZoneID: req.ZoneId})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return status.Error(codes.NotFound, "zone not found")
+		}
+		return rezone(tx, req.ZoneId)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.DeleteZoneResponse{Status: "DELETED"}, nil
+}
+
+// zoneAt returns the ID of the zone covering p, or an empty string when p is
+// outside every zone.
+func zoneAt(tx *gorm.DB, p Point) (string, error) {
+	var zones []Zone
+	if err := tx.Select("zone_id").
+		Where("ST_Covers(boundary, ?::geography)", p).
+		Order("zone_id").
+		Limit(1).
+		Find(&zones).Error; err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", nil
+	}
+	return zones[0].ZoneID, nil
+}
+
+// rezone recomputes zone_id, the way zoneAt does, for the delivery people
+// and unfinished orders that were in zoneID or are covered by it now, after
+// the zone was created, reshaped or deleted.
+func rezone(tx *gorm.DB, zoneID string) error {
+	covering := func(column string) clause.Expr {
+		return gorm.Expr("COALESCE((SELECT zones.zone_id FROM zones WHERE ST_Covers(zones.boundary, " + column + ") ORDER BY zones.zone_id LIMIT 1), '')")
+	}
+	if err := tx.Model(&DeliveryPerson{}).
+		Where("zone_id = ? OR ST_Covers((SELECT boundary FROM zones WHERE zone_id = ?), location)", zoneID, zoneID).
+		Update("zone_id", covering("delivery_people.location")).Error; err != nil {
+		return err
+	}
+	return tx.Model(&Order{}).
+		Where("status NOT IN ? AND (zone_id = ? OR ST_Covers((SELECT boundary FROM zones WHERE zone_id = ?), pickup))",
+			[]string{OrderStatusDelivered, OrderStatusReturned}, zoneID, zoneID).
+		Update("zone_id", covering("orders.pickup")).Error
+}
+
+// inZone limits a delivery_people query to the order's zone. Orders outside
+// every zone can be served by anyone.
+func inZone(order *Order) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if order.ZoneID == "" {
+			return db
+		}
+		return db.Where("delivery_people.zone_id = ?", order.ZoneID)
+	}
+}
+
+func validateZone(name, geojson string) error {
+	if name == "" {
+		return status.Error(codes.InvalidArgument, "zone name is required")
+	}
+	if err := validatePolygon(geojson); err != nil {
+		return status.Errorf(codes.InvalidArgument, "invalid zone boundary: %v", err)
+	}
+	return nil
+}
+
+// validatePolygon checks that geojson is a GeoJSON Polygon whose rings are
+// closed and have at least four positions.
+func validatePolygon(geojson string) error {
+	var geometry struct {
+		Type        string        `json:"type"`
+		Coordinates [][][]float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal([]byte(geojson), &geometry); err != nil {
+		return err
+	}
+	if geometry.Type != "Polygon" {
+		return fmt.Errorf("expected a Polygon, got %q", geometry.Type)
+	}
+	if len(geometry.Coordinates) == 0 {
+		return fmt.Errorf("polygon has no rings")
+	}
+	for _, ring := range geometry.Coordinates {
+		if len(ring) < 4 {
+			return fmt.Errorf("ring needs at least 4 positions, got %d", len(ring))
+		}
+		for _, position := range ring {
+			if len(position) < 2 {
+				return fmt.Errorf("position needs a longitude and latitude")
+			}
+			if position[0] < -180 || position[0] > 180 || position[1] < -90 || position[1] > 90 {
+				return fmt.Errorf("position %v is out of range", position)
+			}
+		}
+		first, last := ring[0], ring[len(ring)-1]
+		if first[0] != last[0] || first[1] != last[1] {
+			return fmt.Errorf("ring is not closed")
+		}
+	}
+	return nil
+}
+
+func zoneToProto(zone *Zone) *pb.Zone {
+	return &pb.Zone{
+		ZoneId:  zone.ZoneID,
+		Name:    zone.Name,
+		Geojson: string(zone.Boundary),
+	}
+}
@@ -0,0 +1,198 @@
+package fulfillment
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "fullfillment-service/proto"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const midtownGeoJSON = `{"type":"Polygon","coordinates":[[[-74.02,40.74],[-73.96,40.74],[-73.96,40.77],[-74.02,40.77],[-74.02,40.74]]]}`
+
+// expectZoneLookup expects a point-in-zone lookup and answers it with zoneID,
+// or with no zone when zoneID is empty.
+func expectZoneLookup(mock sqlmock.Sqlmock, zoneID string) {
+	rows := sqlmock.NewRows([]string{"zone_id"})
+	if zoneID != "" {
+		rows.AddRow(zoneID)
+	}
+	mock.ExpectQuery(`SELECT "zone_id" FROM "zones" WHERE ST_Covers\(boundary, \$1::geography\) ORDER BY zone_id LIMIT \$2`).
+		WillReturnRows(rows)
+}
+
+// expectRezone expects the delivery people and unfinished orders in or
+// under zoneID to have their zone recomputed.
+func expectRezone(mock sqlmock.Sqlmock, zoneID interface{}) {
+	mock.ExpectExec(`UPDATE "delivery_people" SET "zone_id"=COALESCE\(\(SELECT zones\.zone_id FROM zones WHERE ST_Covers\(zones\.boundary, delivery_people\.location\) ORDER BY zones\.zone_id LIMIT 1\), ''\) WHERE zone_id = \$1 OR ST_Covers\(\(SELECT boundary FROM zones WHERE zone_id = \$2\), location\)`).
+		WithArgs(zoneID, zoneID).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`UPDATE "orders" SET "zone_id"=COALESCE\(\(SELECT zones\.zone_id FROM zones WHERE ST_Covers\(zones\.boundary, orders\.pickup\) ORDER BY zones\.zone_id LIMIT 1\), ''\),"updated_at"=\$1 WHERE status NOT IN \(\$2,\$3\) AND \(zone_id = \$4 OR ST_Covers\(\(SELECT boundary FROM zones WHERE zone_id = \$5\), pickup\)\)`).
+		WithArgs(sqlmock.AnyArg(), "DELIVERED", "RETURNED", zoneID, zoneID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+}
+
+func TestValidatePolygon(t *testing.T) {
+	assert.NoError(t, validatePolygon(midtownGeoJSON))
+	assert.Error(t, validatePolygon(`{"type":"Point","coordinates":[-73.98,40.75]}`))
+	assert.Error(t, validatePolygon(`{"type":"Polygon","coordinates":[[[-74.02,40.74],[-73.96,40.74],[-73.96,40.77],[-74.02,40.77]]]}`))
+	assert.Error(t, validatePolygon(`{"type":"Polygon","coordinates":[[[-74.02,40.74],[-73.96,40.74],[-74.02,40.74]]]}`))
+	assert.Error(t, validatePolygon(`{"type":"Polygon","coordinates":[[[-200,40.74],[-73.96,40.74],[-73.96,40.77],[-200,40.74]]]}`))
+	assert.Error(t, validatePolygon(`not json`))
+}
+
+func TestCreateZone(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Stores Boundary As Geography", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "zones" \("zone_id","name","boundary","created_at","updated_at"\) VALUES \(\$1,\$2,ST_GeomFromGeoJSON\(\$3\)::geography,\$4,\$5\)`).
+			WithArgs(sqlmock.AnyArg(), "Midtown", midtownGeoJSON, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		expectRezone(mock, sqlmock.AnyArg())
+		mock.ExpectCommit()
+
+		resp, err := service.CreateZone(context.Background(), &pb.CreateZoneRequest{Name: "Midtown", Geojson: midtownGeoJSON})
+
+		assert.NoError(t, err)
+		assert.NotEmpty(t, resp.Zone.ZoneId)
+		assert.Equal(t, midtownGeoJSON, resp.Zone.Geojson)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Invalid Boundary", func(t *testing.T) {
+		resp, err := service.CreateZone(context.Background(), &pb.CreateZoneRequest{Name: "Midtown", Geojson: `{"type":"Point"}`})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestGetZone(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Returns GeoJSON Boundary", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT zone_id, name, ST_AsGeoJSON\(boundary\) AS boundary, created_at, updated_at FROM "zones" WHERE zone_id = \$1`).
+			WithArgs("zone1", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"zone_id", "name", "boundary"}).AddRow("zone1", "Midtown", midtownGeoJSON))
+
+		resp, err := service.GetZone(context.Background(), &pb.GetZoneRequest{ZoneId: "zone1"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Midtown", resp.Zone.Name)
+		assert.Equal(t, midtownGeoJSON, resp.Zone.Geojson)
+	})
+
+	t.Run("Failure - Unknown Zone", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT zone_id, name, ST_AsGeoJSON\(boundary\) AS boundary, created_at, updated_at FROM "zones" WHERE zone_id = \$1`).
+			WithArgs("missing", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"zone_id"}))
+
+		resp, err := service.GetZone(context.Background(), &pb.GetZoneRequest{ZoneId: "missing"})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+	})
+}
+
+func TestUpdateZone(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	t.Run("Success - Recomputes Zone Membership", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "zones" SET "boundary"=ST_GeomFromGeoJSON\(\$1\)::geography,"name"=\$2,"updated_at"=\$3 WHERE "zone_id" = \$4`).
+			WithArgs(midtownGeoJSON, "Midtown West", sqlmock.AnyArg(), "zone1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		expectRezone(mock, "zone1")
+		mock.ExpectCommit()
+
+		resp, err := service.UpdateZone(context.Background(), &pb.UpdateZoneRequest{ZoneId: "zone1", Name: "Midtown West", Geojson: midtownGeoJSON})
+
+		assert.NoError(t, err)
+		assert.Equal(t, "Midtown West", resp.Zone.Name)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure - Unknown Zone", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "zones" SET "boundary"=ST_GeomFromGeoJSON\(\$1\)::geography,"name"=\$2,"updated_at"=\$3 WHERE "zone_id" = \$4`).
+			WithArgs(midtownGeoJSON, "Midtown West", sqlmock.AnyArg(), "zone2").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		resp, err := service.UpdateZone(context.Background(), &pb.UpdateZoneRequest{ZoneId: "zone2", Name: "Midtown West", Geojson: midtownGeoJSON})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.NotFound, status.Code(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestDeleteZone(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	service := NewService(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM "zones" WHERE "zones"\."zone_id" = \$1`).
+		WithArgs("zone1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectRezone(mock, "zone1")
+	mock.ExpectCommit()
+
+	resp, err := service.DeleteZone(context.Background(), &pb.DeleteZoneRequest{ZoneId: "zone1"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "DELETED", resp.Status)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAssignOrderStaysInZone(t *testing.T) {
+	db, mock, sqlDB := setupMockDB(t)
+	defer sqlDB.Close()
+
+	now := time.Unix(1700000000, 0)
+	cfg := DefaultConfig()
+	cfg.BatchWindow = 0
+	service := NewService(db, WithConfig(cfg), WithClock(func() time.Time { return now }))
+
+	mock.ExpectBegin()
+	expectZoneLookup(mock, "midtown")
+	mock.ExpectExec(`INSERT INTO "orders"`).
+		WithArgs(orderInsertArgs("order1", "OFFERED", 0.0, 0.0, "", sqlmock.AnyArg(), sqlmock.AnyArg(), "", "midtown")...).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT \* FROM "delivery_people" WHERE status = \$1 AND delivery_person_id NOT IN \(.*\) AND ST_DWithin\(.*\) AND delivery_people\.zone_id = \$6 AND`).
+		WithArgs("AVAILABLE", "order1", "PENDING", sqlmock.AnyArg(), 1000.0, "midtown",
+			"BIKE", "SCOOTER", "CAR", "VAN",
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg(),
+			"ASSIGNED", "IN_PROGRESS", "RETURNING", sqlmock.AnyArg(), sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"delivery_person_id", "status", "zone_id"}).AddRow("dp1", "AVAILABLE", "midtown"))
+	mock.ExpectExec(`INSERT INTO "offers"`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	expectOfferEstimate(mock, "dp1", nearbyHouse)
+	mock.ExpectCommit()
+
+	resp, err := service.AssignOrder(context.Background(), &pb.AssignOrderRequest{
+		OrderId: "order1",
+		Pickup:  &pb.Location{Lat: restaurant.Lat, Lng: restaurant.Lng},
+		Dropoff: &pb.Location{Lat: nextDoor.Lat, Lng: nextDoor.Lng},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "dp1", resp.DeliveryPersonId)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,131 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// RequestIDHeader is the metadata key that carries the request ID. A caller
+// may set it to correlate our logs with theirs; otherwise, or when theirs is
+// not a valid request ID, one is generated. Either way it is sent back in
+// the response headers.
+const RequestIDHeader = "x-request-id"
+
+// maxRequestIDLength bounds a request ID taken from a caller.
+const maxRequestIDLength = 128
+
+// UnaryServerInterceptor gives each RPC a logger tagged with its request ID
+// and method, and logs the outcome once it is handled.
+func UnaryServerInterceptor(base *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, logger := start(ctx, base, info.FullMethod)
+		begin := time.Now()
+		resp, err := handler(ctx, req)
+		finish(ctx, logger.With(requestFields(req)...), begin, err)
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor is UnaryServerInterceptor for streaming RPCs.
+func StreamServerInterceptor(base *slog.Logger) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, logger := start(ss.Context(), base, info.FullMethod)
+		begin := time.Now()
+		err := handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		finish(ctx, logger, begin, err)
+		return err
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func start(ctx context.Context, base *slog.Logger, method string) (context.Context, *slog.Logger) {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(RequestIDHeader); len(values) > 0 && validRequestID(values[0]) {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+
+	logger := base.With("request_id", requestID, "method", method)
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		logger = logger.With("trace_id", span.TraceID().String())
+	}
+	return WithLogger(ctx, logger), logger
+}
+
+func finish(ctx context.Context, logger *slog.Logger, begin time.Time, err error) {
+	code := status.Code(err)
+	level := slog.LevelInfo
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss, codes.Unavailable, codes.DeadlineExceeded:
+		level = slog.LevelError
+	}
+
+	attrs := []slog.Attr{
+		slog.String("code", code.String()),
+		slog.Duration("duration", time.Since(begin)),
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	logger.LogAttrs(ctx, level, "rpc handled", attrs...)
+}
+
+// requestFields picks the IDs worth searching logs by out of a request.
+func requestFields(req interface{}) []any {
+	var fields []any
+	if r, ok := req.(interface{ GetOrderId() string }); ok && r.GetOrderId() != "" {
+		fields = append(fields, "order_id", r.GetOrderId())
+	}
+	if r, ok := req.(interface{ GetDeliveryPersonId() string }); ok && r.GetDeliveryPersonId() != "" {
+		fields = append(fields, "delivery_person_id", r.GetDeliveryPersonId())
+	}
+	return fields
+}
+
+// validRequestID reports whether a caller's request ID is short enough and
+// made only of letters, digits and the punctuation IDs are usually built
+// from, so it cannot bloat or forge log lines.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,86 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	pb "fullfillment-service/proto"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(DefaultConfig(), &buf)
+	require.NoError(t, err)
+	interceptor := UnaryServerInterceptor(logger)
+	info := &grpc.UnaryServerInfo{FullMethod: "/fulfillment.FulfillmentService/CompleteDelivery"}
+
+	decode := func() map[string]interface{} {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+		buf.Reset()
+		return record
+	}
+
+	t.Run("Propagates Request ID", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, "req-123"))
+		req := &pb.CompleteDeliveryRequest{OrderId: "order1", DeliveryPersonId: "dp1"}
+
+		_, err := interceptor(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			FromContext(ctx).Info("inside handler")
+			return &pb.CompleteDeliveryResponse{}, nil
+		})
+		require.NoError(t, err)
+
+		lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+		require.Len(t, lines, 2)
+		var inside, handled map[string]interface{}
+		require.NoError(t, json.Unmarshal(lines[0], &inside))
+		require.NoError(t, json.Unmarshal(lines[1], &handled))
+		buf.Reset()
+
+		assert.Equal(t, "req-123", inside["request_id"])
+		assert.Equal(t, "req-123", handled["request_id"])
+		assert.Equal(t, info.FullMethod, handled["method"])
+		assert.Equal(t, "OK", handled["code"])
+		assert.Equal(t, "order1", handled["order_id"])
+		assert.Equal(t, "dp1", handled["delivery_person_id"])
+		assert.Contains(t, handled, "duration")
+	})
+
+	t.Run("Generates Request ID", func(t *testing.T) {
+		_, err := interceptor(context.Background(), &pb.GetOrderStatusRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.Internal, "boom")
+		})
+		assert.Error(t, err)
+
+		record := decode()
+		assert.Len(t, record["request_id"], 32)
+		assert.Equal(t, "ERROR", record["level"])
+		assert.Equal(t, "Internal", record["code"])
+		assert.Equal(t, "rpc error: code = Internal desc = boom", record["error"])
+		assert.NotContains(t, record, "order_id")
+	})
+
+	t.Run("Replaces Invalid Request ID", func(t *testing.T) {
+		for _, id := range []string{strings.Repeat("a", 129), "req-1\nlevel=ERROR", "req 1", "<script>"} {
+			ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(RequestIDHeader, id))
+			_, err := interceptor(ctx, &pb.GetOrderStatusRequest{}, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return &pb.GetOrderStatusResponse{}, nil
+			})
+			require.NoError(t, err)
+
+			record := decode()
+			assert.Len(t, record["request_id"], 32, id)
+		}
+	})
+}
@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(Config{Level: "warn", Format: "text"}, &buf)
+	require.NoError(t, err)
+
+	logger.Info("hidden")
+	logger.Warn("shown", "order_id", "order1")
+	assert.NotContains(t, buf.String(), "hidden")
+	assert.Contains(t, buf.String(), "msg=shown order_id=order1")
+
+	_, err = New(Config{Level: "loud", Format: FormatJSON}, &buf)
+	assert.EqualError(t, err, `unknown log level "loud"`)
+	_, err = New(Config{Level: "info", Format: "xml"}, &buf)
+	assert.EqualError(t, err, `unknown log format "xml"`)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Equal(t, slog.Default(), FromContext(context.Background()))
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Equal(t, logger, FromContext(WithLogger(context.Background(), logger)))
+}
@@ -0,0 +1,66 @@
+// Package metrics exposes the service's Prometheus metrics: per-method gRPC
+// server metrics, database connection pool stats and the /metrics handler.
+// Domain metrics are defined next to the code that records them.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	rpcHandled = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "grpc",
+		Subsystem: "server",
+		Name:      "handled_total",
+		Help:      "RPCs completed on the server, by method and status code.",
+	}, []string{"grpc_service", "grpc_method", "grpc_code"})
+
+	rpcDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "grpc",
+		Subsystem: "server",
+		Name:      "handling_seconds",
+		Help:      "Time taken to handle RPCs on the server, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"grpc_service", "grpc_method"})
+)
+
+// UnaryServerInterceptor counts and times every unary RPC.
+func UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+
+	service, method := splitMethod(info.FullMethod)
+	rpcDuration.WithLabelValues(service, method).Observe(time.Since(start).Seconds())
+	rpcHandled.WithLabelValues(service, method, status.Code(err).String()).Inc()
+	return resp, err
+}
+
+// splitMethod splits "/package.Service/Method" into its service and method.
+func splitMethod(fullMethod string) (string, string) {
+	service, method, ok := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	if !ok {
+		return "unknown", "unknown"
+	}
+	return service, method
+}
+
+// RegisterDBStats exports the connection pool stats of db.
+func RegisterDBStats(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// Handler serves the metrics in the default registry.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	info := &grpc.UnaryServerInfo{FullMethod: "/fulfillment.FulfillmentService/AssignOrder"}
+	ok := func(ctx context.Context, req interface{}) (interface{}, error) { return "resp", nil }
+	failed := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, status.Error(codes.ResourceExhausted, "no driver")
+	}
+
+	resp, err := UnaryServerInterceptor(context.Background(), "req", info, ok)
+	assert.NoError(t, err)
+	assert.Equal(t, "resp", resp)
+	_, err = UnaryServerInterceptor(context.Background(), "req", info, failed)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcHandled.WithLabelValues("fulfillment.FulfillmentService", "AssignOrder", "OK")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(rpcHandled.WithLabelValues("fulfillment.FulfillmentService", "AssignOrder", "ResourceExhausted")))
+}
+
+func TestSplitMethod(t *testing.T) {
+	service, method := splitMethod("/fulfillment.FulfillmentService/GetOrderStatus")
+	assert.Equal(t, "fulfillment.FulfillmentService", service)
+	assert.Equal(t, "GetOrderStatus", method)
+
+	service, method = splitMethod("garbage")
+	assert.Equal(t, "unknown", service)
+	assert.Equal(t, "unknown", method)
+}
+
+func TestHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, 200, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), "go_goroutines"))
+}
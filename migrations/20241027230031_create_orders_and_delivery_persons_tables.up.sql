@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+CREATE TABLE IF NOT EXISTS delivery_people (
+    delivery_person_id VARCHAR(64) PRIMARY KEY,
+    name               VARCHAR(255) NOT NULL,
+    status             VARCHAR(32)  NOT NULL DEFAULT 'AVAILABLE',
+    location           GEOGRAPHY(POINT, 4326)
+);
+
+-- delivery_person_id is NULL while nobody is assigned. Writing '' instead
+-- fails the foreign key.
+CREATE TABLE IF NOT EXISTS orders (
+    order_id           VARCHAR(64) PRIMARY KEY,
+    delivery_person_id VARCHAR(64) REFERENCES delivery_people (delivery_person_id),
+    status             VARCHAR(32) NOT NULL,
+    created_at         BIGINT      NOT NULL DEFAULT 0,
+    updated_at         BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_person_id ON orders (delivery_person_id);
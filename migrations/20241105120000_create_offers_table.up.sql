@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS offers (
+    offer_id           VARCHAR(64) PRIMARY KEY,
+    order_id           VARCHAR(64) NOT NULL REFERENCES orders (order_id),
+    delivery_person_id VARCHAR(64) NOT NULL REFERENCES delivery_people (delivery_person_id),
+    status             VARCHAR(32) NOT NULL,
+    decline_reason     TEXT        NOT NULL DEFAULT '',
+    expires_at         BIGINT      NOT NULL,
+    responded_at       BIGINT      NOT NULL DEFAULT 0,
+    created_at         BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_offers_order_id ON offers (order_id);
+CREATE INDEX IF NOT EXISTS idx_offers_pending_expiry ON offers (expires_at) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_offers_delivery_person_status ON offers (delivery_person_id, status);
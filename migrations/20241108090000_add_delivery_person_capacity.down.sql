@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_orders_delivery_person_status;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS volume_liters,
+    DROP COLUMN IF EXISTS weight_kg;
+
+ALTER TABLE delivery_people
+    DROP COLUMN IF EXISTS max_volume_liters,
+    DROP COLUMN IF EXISTS max_weight_kg,
+    DROP COLUMN IF EXISTS capacity;
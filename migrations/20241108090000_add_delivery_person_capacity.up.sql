@@ -0,0 +1,10 @@
+ALTER TABLE delivery_people
+    ADD COLUMN IF NOT EXISTS capacity          INTEGER          NOT NULL DEFAULT 1,
+    ADD COLUMN IF NOT EXISTS max_weight_kg     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS max_volume_liters DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS weight_kg     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS volume_liters DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_orders_delivery_person_status ON orders (delivery_person_id, status);
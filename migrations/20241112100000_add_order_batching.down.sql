@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_orders_batch_id;
+DROP INDEX IF EXISTS idx_orders_pickup;
+
+ALTER TABLE offers
+    DROP COLUMN IF EXISTS batch_id;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS batch_id,
+    DROP COLUMN IF EXISTS dropoff,
+    DROP COLUMN IF EXISTS pickup;
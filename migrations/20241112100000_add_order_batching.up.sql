@@ -0,0 +1,10 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS pickup   GEOGRAPHY(POINT, 4326),
+    ADD COLUMN IF NOT EXISTS dropoff  GEOGRAPHY(POINT, 4326),
+    ADD COLUMN IF NOT EXISTS batch_id VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE offers
+    ADD COLUMN IF NOT EXISTS batch_id VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_pickup ON orders USING GIST (pickup);
+CREATE INDEX IF NOT EXISTS idx_orders_batch_id ON orders (batch_id) WHERE batch_id <> '';
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS route_stops (
+    delivery_person_id VARCHAR(64)      NOT NULL REFERENCES delivery_people (delivery_person_id),
+    sequence           INTEGER          NOT NULL,
+    order_id           VARCHAR(64)      NOT NULL REFERENCES orders (order_id),
+    kind               VARCHAR(16)      NOT NULL,
+    location           GEOGRAPHY(POINT, 4326),
+    leg_meters         DOUBLE PRECISION NOT NULL DEFAULT 0,
+    PRIMARY KEY (delivery_person_id, sequence)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS delivery_eta,
+    DROP COLUMN IF EXISTS pickup_eta;
@@ -0,0 +1,3 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS pickup_eta   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS delivery_eta BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_delivery_people_zone_id;
+
+ALTER TABLE delivery_people
+    DROP COLUMN IF EXISTS zone_id;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS zone_id;
+
+DROP TABLE IF EXISTS zones;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS zones (
+    zone_id    VARCHAR(64) PRIMARY KEY,
+    name       VARCHAR(255) NOT NULL,
+    boundary   GEOGRAPHY(POLYGON, 4326) NOT NULL,
+    created_at BIGINT NOT NULL DEFAULT 0,
+    updated_at BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_zones_boundary ON zones USING GIST (boundary);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS zone_id VARCHAR(64) NOT NULL DEFAULT '';
+
+ALTER TABLE delivery_people
+    ADD COLUMN IF NOT EXISTS zone_id VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_delivery_people_zone_id ON delivery_people (zone_id);
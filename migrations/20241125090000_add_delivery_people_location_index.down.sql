@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_delivery_people_location;
@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_delivery_people_location ON delivery_people USING GIST (location);
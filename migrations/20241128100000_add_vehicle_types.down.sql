@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS package_size;
+
+ALTER TABLE delivery_people
+    DROP COLUMN IF EXISTS vehicle_type;
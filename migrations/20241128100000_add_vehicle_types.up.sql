@@ -0,0 +1,5 @@
+ALTER TABLE delivery_people
+    ADD COLUMN IF NOT EXISTS vehicle_type VARCHAR(16) NOT NULL DEFAULT 'CAR';
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS package_size VARCHAR(16) NOT NULL DEFAULT '';
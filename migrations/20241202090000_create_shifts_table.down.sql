@@ -0,0 +1,4 @@
+ALTER TABLE delivery_people
+    ALTER COLUMN status SET DEFAULT 'AVAILABLE';
+
+DROP TABLE IF EXISTS shifts;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS shifts (
+    shift_id           VARCHAR(64) PRIMARY KEY,
+    delivery_person_id VARCHAR(64) NOT NULL REFERENCES delivery_people (delivery_person_id),
+    started_at         BIGINT      NOT NULL,
+    ended_at           BIGINT      NOT NULL DEFAULT 0,
+    start_location     GEOGRAPHY(POINT, 4326),
+    end_location       GEOGRAPHY(POINT, 4326)
+);
+
+-- A delivery person can only have one shift in progress.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shifts_open ON shifts (delivery_person_id) WHERE ended_at = 0;
+
+ALTER TABLE delivery_people
+    ALTER COLUMN status SET DEFAULT 'OFFLINE';
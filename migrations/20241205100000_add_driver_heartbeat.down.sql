@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_orders_needs_attention;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS attention_reason,
+    DROP COLUMN IF EXISTS needs_attention;
+
+DROP INDEX IF EXISTS idx_delivery_people_status_last_seen;
+
+ALTER TABLE delivery_people
+    DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,10 @@
+ALTER TABLE delivery_people
+    ADD COLUMN IF NOT EXISTS last_seen_at BIGINT NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_delivery_people_status_last_seen ON delivery_people (status, last_seen_at);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS needs_attention  BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS attention_reason VARCHAR(64) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_needs_attention ON orders (needs_attention) WHERE needs_attention;
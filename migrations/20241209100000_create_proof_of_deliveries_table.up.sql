@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS proof_of_deliveries (
+    order_id           VARCHAR(64)            PRIMARY KEY REFERENCES orders (order_id),
+    delivery_person_id VARCHAR(64)            NOT NULL REFERENCES delivery_people (delivery_person_id),
+    photo_hash         VARCHAR(128)           NOT NULL DEFAULT '',
+    photo_ref          TEXT                   NOT NULL DEFAULT '',
+    recipient_name     VARCHAR(255)           NOT NULL DEFAULT '',
+    signature          BYTEA,
+    location           GEOGRAPHY(POINT, 4326) NOT NULL,
+    distance_meters    DOUBLE PRECISION       NOT NULL DEFAULT 0,
+    captured_at        BIGINT                 NOT NULL
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS delivery_pins (
+    order_id        VARCHAR(64)  PRIMARY KEY REFERENCES orders (order_id),
+    pin             VARCHAR(16)  NOT NULL,
+    attempts        INTEGER      NOT NULL DEFAULT 0,
+    verified_at     BIGINT       NOT NULL DEFAULT 0,
+    overridden_by   VARCHAR(255) NOT NULL DEFAULT '',
+    override_reason TEXT         NOT NULL DEFAULT '',
+    created_at      BIGINT       NOT NULL DEFAULT 0
+);
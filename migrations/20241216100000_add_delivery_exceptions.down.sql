@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS delivery_exceptions;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS retry_after,
+    DROP COLUMN IF EXISTS delivery_attempts;
@@ -0,0 +1,17 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS delivery_attempts INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS retry_after       BIGINT  NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS delivery_exceptions (
+    exception_id       VARCHAR(64) PRIMARY KEY,
+    order_id           VARCHAR(64) NOT NULL REFERENCES orders (order_id),
+    delivery_person_id VARCHAR(64) NOT NULL REFERENCES delivery_people (delivery_person_id),
+    reason             VARCHAR(32) NOT NULL,
+    note               TEXT        NOT NULL DEFAULT '',
+    location           GEOGRAPHY(POINT, 4326),
+    attempt            INTEGER     NOT NULL,
+    action             VARCHAR(16) NOT NULL,
+    reported_at        BIGINT      NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_delivery_exceptions_order_id ON delivery_exceptions (order_id);
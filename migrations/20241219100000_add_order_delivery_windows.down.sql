@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_orders_scheduled_dispatch;
+
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS window_outcome,
+    DROP COLUMN IF EXISTS dispatch_at,
+    DROP COLUMN IF EXISTS window_end,
+    DROP COLUMN IF EXISTS window_start;
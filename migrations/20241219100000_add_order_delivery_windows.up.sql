@@ -0,0 +1,7 @@
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS window_start   BIGINT      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS window_end     BIGINT      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS dispatch_at    BIGINT      NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS window_outcome VARCHAR(16) NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_orders_scheduled_dispatch ON orders (dispatch_at) WHERE status = 'SCHEDULED';
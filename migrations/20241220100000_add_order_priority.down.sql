@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_orders_assigned_priority;
+
+ALTER TABLE orders DROP COLUMN IF EXISTS priority;
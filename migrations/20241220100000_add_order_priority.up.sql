@@ -0,0 +1,3 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS priority VARCHAR(16) NOT NULL DEFAULT 'STANDARD';
+
+CREATE INDEX IF NOT EXISTS idx_orders_assigned_priority ON orders (priority) WHERE status = 'ASSIGNED';
@@ -0,0 +1,5 @@
+ALTER TABLE orders
+    DROP COLUMN IF EXISTS sla_due_at,
+    DROP COLUMN IF EXISTS sla_status;
+
+DROP TABLE IF EXISTS sla_targets;
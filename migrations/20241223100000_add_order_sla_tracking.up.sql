@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS sla_targets (
+    zone_id        VARCHAR(64) NOT NULL DEFAULT '',
+    priority       VARCHAR(16) NOT NULL DEFAULT '',
+    target_seconds BIGINT      NOT NULL,
+    updated_at     BIGINT      NOT NULL DEFAULT 0,
+    PRIMARY KEY (zone_id, priority)
+);
+
+ALTER TABLE orders
+    ADD COLUMN IF NOT EXISTS sla_status VARCHAR(16) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS sla_due_at BIGINT      NOT NULL DEFAULT 0;
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL   PRIMARY KEY,
+    event_id        VARCHAR(64) NOT NULL UNIQUE,
+    aggregate_id    VARCHAR(64) NOT NULL,
+    event_type      VARCHAR(64) NOT NULL,
+    payload         JSONB       NOT NULL,
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT      NOT NULL DEFAULT 0,
+    last_error      TEXT        NOT NULL DEFAULT '',
+    created_at      BIGINT      NOT NULL DEFAULT 0,
+    published_at    BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (id) WHERE published_at = 0;
+
+CREATE TABLE IF NOT EXISTS dead_letter_events (
+    event_id     VARCHAR(64) PRIMARY KEY,
+    aggregate_id VARCHAR(64) NOT NULL,
+    event_type   VARCHAR(64) NOT NULL,
+    payload      JSONB       NOT NULL,
+    attempts     INTEGER     NOT NULL,
+    last_error   TEXT        NOT NULL DEFAULT '',
+    created_at   BIGINT      NOT NULL DEFAULT 0,
+    failed_at    BIGINT      NOT NULL
+);
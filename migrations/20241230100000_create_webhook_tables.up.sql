@@ -0,0 +1,29 @@
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+    subscription_id      VARCHAR(64) PRIMARY KEY,
+    url                  TEXT        NOT NULL,
+    event_types          TEXT        NOT NULL DEFAULT '',
+    secret               TEXT        NOT NULL,
+    active               BOOLEAN     NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER     NOT NULL DEFAULT 0,
+    disabled_at          BIGINT      NOT NULL DEFAULT 0,
+    created_at           BIGINT      NOT NULL DEFAULT 0,
+    updated_at           BIGINT      NOT NULL DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    delivery_id      VARCHAR(64) PRIMARY KEY,
+    subscription_id  VARCHAR(64) NOT NULL REFERENCES webhook_subscriptions (subscription_id) ON DELETE CASCADE,
+    event_id         VARCHAR(64) NOT NULL,
+    event_type       VARCHAR(64) NOT NULL,
+    payload          JSONB       NOT NULL,
+    status           VARCHAR(16) NOT NULL DEFAULT 'PENDING',
+    attempts         INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at  BIGINT      NOT NULL DEFAULT 0,
+    last_status_code INTEGER     NOT NULL DEFAULT 0,
+    last_error       TEXT        NOT NULL DEFAULT '',
+    created_at       BIGINT      NOT NULL DEFAULT 0,
+    delivered_at     BIGINT      NOT NULL DEFAULT 0,
+    UNIQUE (subscription_id, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_pending ON webhook_deliveries (next_attempt_at) WHERE status = 'PENDING';
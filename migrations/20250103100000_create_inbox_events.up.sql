@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS inbox_events (
+    id              BIGSERIAL PRIMARY KEY,
+    payload         JSONB     NOT NULL,
+    attempts        INTEGER   NOT NULL DEFAULT 0,
+    next_attempt_at BIGINT    NOT NULL DEFAULT 0,
+    last_error      TEXT      NOT NULL DEFAULT '',
+    created_at      BIGINT    NOT NULL DEFAULT 0,
+    processed_at    BIGINT    NOT NULL DEFAULT 0,
+    dead_at         BIGINT    NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_inbox_events_pending ON inbox_events (id) WHERE processed_at = 0 AND dead_at = 0;
@@ -0,0 +1 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS status_changed_at;
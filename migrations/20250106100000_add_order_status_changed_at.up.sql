@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS status_changed_at BIGINT NOT NULL DEFAULT 0;
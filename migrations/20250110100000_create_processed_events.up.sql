@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS processed_events (
+    event_id     VARCHAR(64) PRIMARY KEY,
+    processed_at BIGINT      NOT NULL DEFAULT 0
+);
@@ -22,6 +22,32 @@ type FulfillmentServiceClient interface {
 	GetOrderStatus(ctx context.Context, in *GetOrderStatusRequest, opts ...grpc.CallOption) (*GetOrderStatusResponse, error)
 	UpdateOrderStatus(ctx context.Context, in *UpdateOrderStatusRequest, opts ...grpc.CallOption) (*UpdateOrderStatusResponse, error)
 	GetOrdersByDeliveryPerson(ctx context.Context, in *GetOrdersByDeliveryPersonRequest, opts ...grpc.CallOption) (*GetOrdersByDeliveryPersonResponse, error)
+	AcceptOffer(ctx context.Context, in *AcceptOfferRequest, opts ...grpc.CallOption) (*AcceptOfferResponse, error)
+	DeclineOffer(ctx context.Context, in *DeclineOfferRequest, opts ...grpc.CallOption) (*DeclineOfferResponse, error)
+	GetDriverRoute(ctx context.Context, in *GetDriverRouteRequest, opts ...grpc.CallOption) (*GetDriverRouteResponse, error)
+	UpdateDeliveryPersonLocation(ctx context.Context, in *UpdateDeliveryPersonLocationRequest, opts ...grpc.CallOption) (*UpdateDeliveryPersonLocationResponse, error)
+	CreateZone(ctx context.Context, in *CreateZoneRequest, opts ...grpc.CallOption) (*CreateZoneResponse, error)
+	GetZone(ctx context.Context, in *GetZoneRequest, opts ...grpc.CallOption) (*GetZoneResponse, error)
+	ListZones(ctx context.Context, in *ListZonesRequest, opts ...grpc.CallOption) (*ListZonesResponse, error)
+	UpdateZone(ctx context.Context, in *UpdateZoneRequest, opts ...grpc.CallOption) (*UpdateZoneResponse, error)
+	DeleteZone(ctx context.Context, in *DeleteZoneRequest, opts ...grpc.CallOption) (*DeleteZoneResponse, error)
+	StartShift(ctx context.Context, in *StartShiftRequest, opts ...grpc.CallOption) (*StartShiftResponse, error)
+	EndShift(ctx context.Context, in *EndShiftRequest, opts ...grpc.CallOption) (*EndShiftResponse, error)
+	Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error)
+	CompleteDelivery(ctx context.Context, in *CompleteDeliveryRequest, opts ...grpc.CallOption) (*CompleteDeliveryResponse, error)
+	GetProofOfDelivery(ctx context.Context, in *GetProofOfDeliveryRequest, opts ...grpc.CallOption) (*GetProofOfDeliveryResponse, error)
+	GetDeliveryPin(ctx context.Context, in *GetDeliveryPinRequest, opts ...grpc.CallOption) (*GetDeliveryPinResponse, error)
+	VerifyDeliveryPin(ctx context.Context, in *VerifyDeliveryPinRequest, opts ...grpc.CallOption) (*VerifyDeliveryPinResponse, error)
+	OverrideDeliveryPin(ctx context.Context, in *OverrideDeliveryPinRequest, opts ...grpc.CallOption) (*OverrideDeliveryPinResponse, error)
+	ReportDeliveryException(ctx context.Context, in *ReportDeliveryExceptionRequest, opts ...grpc.CallOption) (*ReportDeliveryExceptionResponse, error)
+	CompleteReturn(ctx context.Context, in *CompleteReturnRequest, opts ...grpc.CallOption) (*CompleteReturnResponse, error)
+	SetSlaTarget(ctx context.Context, in *SetSlaTargetRequest, opts ...grpc.CallOption) (*SetSlaTargetResponse, error)
+	ListSlaTargets(ctx context.Context, in *ListSlaTargetsRequest, opts ...grpc.CallOption) (*ListSlaTargetsResponse, error)
+	CreateWebhookSubscription(ctx context.Context, in *CreateWebhookSubscriptionRequest, opts ...grpc.CallOption) (*CreateWebhookSubscriptionResponse, error)
+	ListWebhookSubscriptions(ctx context.Context, in *ListWebhookSubscriptionsRequest, opts ...grpc.CallOption) (*ListWebhookSubscriptionsResponse, error)
+	DeleteWebhookSubscription(ctx context.Context, in *DeleteWebhookSubscriptionRequest, opts ...grpc.CallOption) (*DeleteWebhookSubscriptionResponse, error)
+	EnableWebhookSubscription(ctx context.Context, in *EnableWebhookSubscriptionRequest, opts ...grpc.CallOption) (*EnableWebhookSubscriptionResponse, error)
+	ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error)
 }
 
 type fulfillmentServiceClient struct {
@@ -68,6 +94,240 @@ func (c *fulfillmentServiceClient) GetOrdersByDeliveryPerson(ctx context.Context
 	return out, nil
 }
 
+func (c *fulfillmentServiceClient) AcceptOffer(ctx context.Context, in *AcceptOfferRequest, opts ...grpc.CallOption) (*AcceptOfferResponse, error) {
+	out := new(AcceptOfferResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/AcceptOffer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) DeclineOffer(ctx context.Context, in *DeclineOfferRequest, opts ...grpc.CallOption) (*DeclineOfferResponse, error) {
+	out := new(DeclineOfferResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/DeclineOffer", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) GetDriverRoute(ctx context.Context, in *GetDriverRouteRequest, opts ...grpc.CallOption) (*GetDriverRouteResponse, error) {
+	out := new(GetDriverRouteResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/GetDriverRoute", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) UpdateDeliveryPersonLocation(ctx context.Context, in *UpdateDeliveryPersonLocationRequest, opts ...grpc.CallOption) (*UpdateDeliveryPersonLocationResponse, error) {
+	out := new(UpdateDeliveryPersonLocationResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/UpdateDeliveryPersonLocation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) CreateZone(ctx context.Context, in *CreateZoneRequest, opts ...grpc.CallOption) (*CreateZoneResponse, error) {
+	out := new(CreateZoneResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/CreateZone", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) GetZone(ctx context.Context, in *GetZoneRequest, opts ...grpc.CallOption) (*GetZoneResponse, error) {
+	out := new(GetZoneResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/GetZone", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) ListZones(ctx context.Context, in *ListZonesRequest, opts ...grpc.CallOption) (*ListZonesResponse, error) {
+	out := new(ListZonesResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/ListZones", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) UpdateZone(ctx context.Context, in *UpdateZoneRequest, opts ...grpc.CallOption) (*UpdateZoneResponse, error) {
+	out := new(UpdateZoneResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/UpdateZone", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) DeleteZone(ctx context.Context, in *DeleteZoneRequest, opts ...grpc.CallOption) (*DeleteZoneResponse, error) {
+	out := new(DeleteZoneResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/DeleteZone", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) StartShift(ctx context.Context, in *StartShiftRequest, opts ...grpc.CallOption) (*StartShiftResponse, error) {
+	out := new(StartShiftResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/StartShift", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) EndShift(ctx context.Context, in *EndShiftRequest, opts ...grpc.CallOption) (*EndShiftResponse, error) {
+	out := new(EndShiftResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/EndShift", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) Heartbeat(ctx context.Context, in *HeartbeatRequest, opts ...grpc.CallOption) (*HeartbeatResponse, error) {
+	out := new(HeartbeatResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/Heartbeat", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) CompleteDelivery(ctx context.Context, in *CompleteDeliveryRequest, opts ...grpc.CallOption) (*CompleteDeliveryResponse, error) {
+	out := new(CompleteDeliveryResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/CompleteDelivery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) GetProofOfDelivery(ctx context.Context, in *GetProofOfDeliveryRequest, opts ...grpc.CallOption) (*GetProofOfDeliveryResponse, error) {
+	out := new(GetProofOfDeliveryResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/GetProofOfDelivery", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) GetDeliveryPin(ctx context.Context, in *GetDeliveryPinRequest, opts ...grpc.CallOption) (*GetDeliveryPinResponse, error) {
+	out := new(GetDeliveryPinResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/GetDeliveryPin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) VerifyDeliveryPin(ctx context.Context, in *VerifyDeliveryPinRequest, opts ...grpc.CallOption) (*VerifyDeliveryPinResponse, error) {
+	out := new(VerifyDeliveryPinResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/VerifyDeliveryPin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) OverrideDeliveryPin(ctx context.Context, in *OverrideDeliveryPinRequest, opts ...grpc.CallOption) (*OverrideDeliveryPinResponse, error) {
+	out := new(OverrideDeliveryPinResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/OverrideDeliveryPin", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) ReportDeliveryException(ctx context.Context, in *ReportDeliveryExceptionRequest, opts ...grpc.CallOption) (*ReportDeliveryExceptionResponse, error) {
+	out := new(ReportDeliveryExceptionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/ReportDeliveryException", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) CompleteReturn(ctx context.Context, in *CompleteReturnRequest, opts ...grpc.CallOption) (*CompleteReturnResponse, error) {
+	out := new(CompleteReturnResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/CompleteReturn", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) SetSlaTarget(ctx context.Context, in *SetSlaTargetRequest, opts ...grpc.CallOption) (*SetSlaTargetResponse, error) {
+	out := new(SetSlaTargetResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/SetSlaTarget", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) ListSlaTargets(ctx context.Context, in *ListSlaTargetsRequest, opts ...grpc.CallOption) (*ListSlaTargetsResponse, error) {
+	out := new(ListSlaTargetsResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/ListSlaTargets", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) CreateWebhookSubscription(ctx context.Context, in *CreateWebhookSubscriptionRequest, opts ...grpc.CallOption) (*CreateWebhookSubscriptionResponse, error) {
+	out := new(CreateWebhookSubscriptionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/CreateWebhookSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) ListWebhookSubscriptions(ctx context.Context, in *ListWebhookSubscriptionsRequest, opts ...grpc.CallOption) (*ListWebhookSubscriptionsResponse, error) {
+	out := new(ListWebhookSubscriptionsResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/ListWebhookSubscriptions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) DeleteWebhookSubscription(ctx context.Context, in *DeleteWebhookSubscriptionRequest, opts ...grpc.CallOption) (*DeleteWebhookSubscriptionResponse, error) {
+	out := new(DeleteWebhookSubscriptionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/DeleteWebhookSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) EnableWebhookSubscription(ctx context.Context, in *EnableWebhookSubscriptionRequest, opts ...grpc.CallOption) (*EnableWebhookSubscriptionResponse, error) {
+	out := new(EnableWebhookSubscriptionResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/EnableWebhookSubscription", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fulfillmentServiceClient) ListWebhookDeliveries(ctx context.Context, in *ListWebhookDeliveriesRequest, opts ...grpc.CallOption) (*ListWebhookDeliveriesResponse, error) {
+	out := new(ListWebhookDeliveriesResponse)
+	err := c.cc.Invoke(ctx, "/proto.FulfillmentService/ListWebhookDeliveries", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FulfillmentServiceServer is the server API for FulfillmentService service.
 // All implementations must embed UnimplementedFulfillmentServiceServer
 // for forward compatibility
@@ -76,6 +336,32 @@ type FulfillmentServiceServer interface {
 	GetOrderStatus(context.Context, *GetOrderStatusRequest) (*GetOrderStatusResponse, error)
 	UpdateOrderStatus(context.Context, *UpdateOrderStatusRequest) (*UpdateOrderStatusResponse, error)
 	GetOrdersByDeliveryPerson(context.Context, *GetOrdersByDeliveryPersonRequest) (*GetOrdersByDeliveryPersonResponse, error)
+	AcceptOffer(context.Context, *AcceptOfferRequest) (*AcceptOfferResponse, error)
+	DeclineOffer(context.Context, *DeclineOfferRequest) (*DeclineOfferResponse, error)
+	GetDriverRoute(context.Context, *GetDriverRouteRequest) (*GetDriverRouteResponse, error)
+	UpdateDeliveryPersonLocation(context.Context, *UpdateDeliveryPersonLocationRequest) (*UpdateDeliveryPersonLocationResponse, error)
+	CreateZone(context.Context, *CreateZoneRequest) (*CreateZoneResponse, error)
+	GetZone(context.Context, *GetZoneRequest) (*GetZoneResponse, error)
+	ListZones(context.Context, *ListZonesRequest) (*ListZonesResponse, error)
+	UpdateZone(context.Context, *UpdateZoneRequest) (*UpdateZoneResponse, error)
+	DeleteZone(context.Context, *DeleteZoneRequest) (*DeleteZoneResponse, error)
+	StartShift(context.Context, *StartShiftRequest) (*StartShiftResponse, error)
+	EndShift(context.Context, *EndShiftRequest) (*EndShiftResponse, error)
+	Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error)
+	CompleteDelivery(context.Context, *CompleteDeliveryRequest) (*CompleteDeliveryResponse, error)
+	GetProofOfDelivery(context.Context, *GetProofOfDeliveryRequest) (*GetProofOfDeliveryResponse, error)
+	GetDeliveryPin(context.Context, *GetDeliveryPinRequest) (*GetDeliveryPinResponse, error)
+	VerifyDeliveryPin(context.Context, *VerifyDeliveryPinRequest) (*VerifyDeliveryPinResponse, error)
+	OverrideDeliveryPin(context.Context, *OverrideDeliveryPinRequest) (*OverrideDeliveryPinResponse, error)
+	ReportDeliveryException(context.Context, *ReportDeliveryExceptionRequest) (*ReportDeliveryExceptionResponse, error)
+	CompleteReturn(context.Context, *CompleteReturnRequest) (*CompleteReturnResponse, error)
+	SetSlaTarget(context.Context, *SetSlaTargetRequest) (*SetSlaTargetResponse, error)
+	ListSlaTargets(context.Context, *ListSlaTargetsRequest) (*ListSlaTargetsResponse, error)
+	CreateWebhookSubscription(context.Context, *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error)
+	ListWebhookSubscriptions(context.Context, *ListWebhookSubscriptionsRequest) (*ListWebhookSubscriptionsResponse, error)
+	DeleteWebhookSubscription(context.Context, *DeleteWebhookSubscriptionRequest) (*DeleteWebhookSubscriptionResponse, error)
+	EnableWebhookSubscription(context.Context, *EnableWebhookSubscriptionRequest) (*EnableWebhookSubscriptionResponse, error)
+	ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error)
 	mustEmbedUnimplementedFulfillmentServiceServer()
 }
 
@@ -95,6 +381,84 @@ func (UnimplementedFulfillmentServiceServer) UpdateOrderStatus(context.Context,
 func (UnimplementedFulfillmentServiceServer) GetOrdersByDeliveryPerson(context.Context, *GetOrdersByDeliveryPersonRequest) (*GetOrdersByDeliveryPersonResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetOrdersByDeliveryPerson not implemented")
 }
+func (UnimplementedFulfillmentServiceServer) AcceptOffer(context.Context, *AcceptOfferRequest) (*AcceptOfferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptOffer not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) DeclineOffer(context.Context, *DeclineOfferRequest) (*DeclineOfferResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeclineOffer not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) GetDriverRoute(context.Context, *GetDriverRouteRequest) (*GetDriverRouteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDriverRoute not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) UpdateDeliveryPersonLocation(context.Context, *UpdateDeliveryPersonLocationRequest) (*UpdateDeliveryPersonLocationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateDeliveryPersonLocation not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) CreateZone(context.Context, *CreateZoneRequest) (*CreateZoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateZone not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) GetZone(context.Context, *GetZoneRequest) (*GetZoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetZone not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) ListZones(context.Context, *ListZonesRequest) (*ListZonesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListZones not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) UpdateZone(context.Context, *UpdateZoneRequest) (*UpdateZoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateZone not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) DeleteZone(context.Context, *DeleteZoneRequest) (*DeleteZoneResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteZone not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) StartShift(context.Context, *StartShiftRequest) (*StartShiftResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StartShift not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) EndShift(context.Context, *EndShiftRequest) (*EndShiftResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EndShift not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) Heartbeat(context.Context, *HeartbeatRequest) (*HeartbeatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Heartbeat not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) CompleteDelivery(context.Context, *CompleteDeliveryRequest) (*CompleteDeliveryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteDelivery not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) GetProofOfDelivery(context.Context, *GetProofOfDeliveryRequest) (*GetProofOfDeliveryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProofOfDelivery not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) GetDeliveryPin(context.Context, *GetDeliveryPinRequest) (*GetDeliveryPinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDeliveryPin not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) VerifyDeliveryPin(context.Context, *VerifyDeliveryPinRequest) (*VerifyDeliveryPinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyDeliveryPin not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) OverrideDeliveryPin(context.Context, *OverrideDeliveryPinRequest) (*OverrideDeliveryPinResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method OverrideDeliveryPin not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) ReportDeliveryException(context.Context, *ReportDeliveryExceptionRequest) (*ReportDeliveryExceptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportDeliveryException not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) CompleteReturn(context.Context, *CompleteReturnRequest) (*CompleteReturnResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CompleteReturn not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) SetSlaTarget(context.Context, *SetSlaTargetRequest) (*SetSlaTargetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetSlaTarget not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) ListSlaTargets(context.Context, *ListSlaTargetsRequest) (*ListSlaTargetsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSlaTargets not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) CreateWebhookSubscription(context.Context, *CreateWebhookSubscriptionRequest) (*CreateWebhookSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWebhookSubscription not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) ListWebhookSubscriptions(context.Context, *ListWebhookSubscriptionsRequest) (*ListWebhookSubscriptionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookSubscriptions not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) DeleteWebhookSubscription(context.Context, *DeleteWebhookSubscriptionRequest) (*DeleteWebhookSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWebhookSubscription not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) EnableWebhookSubscription(context.Context, *EnableWebhookSubscriptionRequest) (*EnableWebhookSubscriptionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnableWebhookSubscription not implemented")
+}
+func (UnimplementedFulfillmentServiceServer) ListWebhookDeliveries(context.Context, *ListWebhookDeliveriesRequest) (*ListWebhookDeliveriesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWebhookDeliveries not implemented")
+}
 func (UnimplementedFulfillmentServiceServer) mustEmbedUnimplementedFulfillmentServiceServer() {}
 
 // UnsafeFulfillmentServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -180,28 +544,600 @@ func _FulfillmentService_GetOrdersByDeliveryPerson_Handler(srv interface{}, ctx
 	return interceptor(ctx, in, info, handler)
 }
 
-// FulfillmentService_ServiceDesc is the grpc.ServiceDesc for FulfillmentService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var FulfillmentService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "proto.FulfillmentService",
-	HandlerType: (*FulfillmentServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "AssignOrder",
-			Handler:    _FulfillmentService_AssignOrder_Handler,
-		},
-		{
-			MethodName: "GetOrderStatus",
-			Handler:    _FulfillmentService_GetOrderStatus_Handler,
-		},
-		{
-			MethodName: "UpdateOrderStatus",
-			Handler:    _FulfillmentService_UpdateOrderStatus_Handler,
-		},
-		{
-			MethodName: "GetOrdersByDeliveryPerson",
-			Handler:    _FulfillmentService_GetOrdersByDeliveryPerson_Handler,
+func _FulfillmentService_AcceptOffer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptOfferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).AcceptOffer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/AcceptOffer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).AcceptOffer(ctx, req.(*AcceptOfferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_DeclineOffer_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeclineOfferRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).DeclineOffer(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/DeclineOffer",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).DeclineOffer(ctx, req.(*DeclineOfferRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_GetDriverRoute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDriverRouteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).GetDriverRoute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/GetDriverRoute",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).GetDriverRoute(ctx, req.(*GetDriverRouteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_UpdateDeliveryPersonLocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateDeliveryPersonLocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).UpdateDeliveryPersonLocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/UpdateDeliveryPersonLocation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).UpdateDeliveryPersonLocation(ctx, req.(*UpdateDeliveryPersonLocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_CreateZone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateZoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).CreateZone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/CreateZone",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).CreateZone(ctx, req.(*CreateZoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_GetZone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetZoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).GetZone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/GetZone",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).GetZone(ctx, req.(*GetZoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_ListZones_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListZonesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).ListZones(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/ListZones",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).ListZones(ctx, req.(*ListZonesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_UpdateZone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateZoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).UpdateZone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/UpdateZone",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).UpdateZone(ctx, req.(*UpdateZoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_DeleteZone_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteZoneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).DeleteZone(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/DeleteZone",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).DeleteZone(ctx, req.(*DeleteZoneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_StartShift_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StartShiftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).StartShift(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/StartShift",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).StartShift(ctx, req.(*StartShiftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_EndShift_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EndShiftRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).EndShift(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/EndShift",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).EndShift(ctx, req.(*EndShiftRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_Heartbeat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HeartbeatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).Heartbeat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/Heartbeat",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).Heartbeat(ctx, req.(*HeartbeatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_CompleteDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).CompleteDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/CompleteDelivery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).CompleteDelivery(ctx, req.(*CompleteDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_GetProofOfDelivery_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProofOfDeliveryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).GetProofOfDelivery(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/GetProofOfDelivery",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).GetProofOfDelivery(ctx, req.(*GetProofOfDeliveryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_GetDeliveryPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeliveryPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).GetDeliveryPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/GetDeliveryPin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).GetDeliveryPin(ctx, req.(*GetDeliveryPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_VerifyDeliveryPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyDeliveryPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).VerifyDeliveryPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/VerifyDeliveryPin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).VerifyDeliveryPin(ctx, req.(*VerifyDeliveryPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_OverrideDeliveryPin_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OverrideDeliveryPinRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).OverrideDeliveryPin(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/OverrideDeliveryPin",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).OverrideDeliveryPin(ctx, req.(*OverrideDeliveryPinRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_ReportDeliveryException_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReportDeliveryExceptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).ReportDeliveryException(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/ReportDeliveryException",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).ReportDeliveryException(ctx, req.(*ReportDeliveryExceptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_CompleteReturn_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompleteReturnRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).CompleteReturn(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/CompleteReturn",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).CompleteReturn(ctx, req.(*CompleteReturnRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_SetSlaTarget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetSlaTargetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).SetSlaTarget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/SetSlaTarget",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).SetSlaTarget(ctx, req.(*SetSlaTargetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_ListSlaTargets_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSlaTargetsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).ListSlaTargets(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/ListSlaTargets",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).ListSlaTargets(ctx, req.(*ListSlaTargetsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_CreateWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).CreateWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/CreateWebhookSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).CreateWebhookSubscription(ctx, req.(*CreateWebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_ListWebhookSubscriptions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookSubscriptionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).ListWebhookSubscriptions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/ListWebhookSubscriptions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).ListWebhookSubscriptions(ctx, req.(*ListWebhookSubscriptionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_DeleteWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).DeleteWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/DeleteWebhookSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).DeleteWebhookSubscription(ctx, req.(*DeleteWebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_EnableWebhookSubscription_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EnableWebhookSubscriptionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).EnableWebhookSubscription(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/EnableWebhookSubscription",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).EnableWebhookSubscription(ctx, req.(*EnableWebhookSubscriptionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FulfillmentService_ListWebhookDeliveries_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWebhookDeliveriesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FulfillmentServiceServer).ListWebhookDeliveries(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.FulfillmentService/ListWebhookDeliveries",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FulfillmentServiceServer).ListWebhookDeliveries(ctx, req.(*ListWebhookDeliveriesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// FulfillmentService_ServiceDesc is the grpc.ServiceDesc for FulfillmentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var FulfillmentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "proto.FulfillmentService",
+	HandlerType: (*FulfillmentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AssignOrder",
+			Handler:    _FulfillmentService_AssignOrder_Handler,
+		},
+		{
+			MethodName: "GetOrderStatus",
+			Handler:    _FulfillmentService_GetOrderStatus_Handler,
+		},
+		{
+			MethodName: "UpdateOrderStatus",
+			Handler:    _FulfillmentService_UpdateOrderStatus_Handler,
+		},
+		{
+			MethodName: "GetOrdersByDeliveryPerson",
+			Handler:    _FulfillmentService_GetOrdersByDeliveryPerson_Handler,
+		},
+		{
+			MethodName: "AcceptOffer",
+			Handler:    _FulfillmentService_AcceptOffer_Handler,
+		},
+		{
+			MethodName: "DeclineOffer",
+			Handler:    _FulfillmentService_DeclineOffer_Handler,
+		},
+		{
+			MethodName: "GetDriverRoute",
+			Handler:    _FulfillmentService_GetDriverRoute_Handler,
+		},
+		{
+			MethodName: "UpdateDeliveryPersonLocation",
+			Handler:    _FulfillmentService_UpdateDeliveryPersonLocation_Handler,
+		},
+		{
+			MethodName: "CreateZone",
+			Handler:    _FulfillmentService_CreateZone_Handler,
+		},
+		{
+			MethodName: "GetZone",
+			Handler:    _FulfillmentService_GetZone_Handler,
+		},
+		{
+			MethodName: "ListZones",
+			Handler:    _FulfillmentService_ListZones_Handler,
+		},
+		{
+			MethodName: "UpdateZone",
+			Handler:    _FulfillmentService_UpdateZone_Handler,
+		},
+		{
+			MethodName: "DeleteZone",
+			Handler:    _FulfillmentService_DeleteZone_Handler,
+		},
+		{
+			MethodName: "StartShift",
+			Handler:    _FulfillmentService_StartShift_Handler,
+		},
+		{
+			MethodName: "EndShift",
+			Handler:    _FulfillmentService_EndShift_Handler,
+		},
+		{
+			MethodName: "Heartbeat",
+			Handler:    _FulfillmentService_Heartbeat_Handler,
+		},
+		{
+			MethodName: "CompleteDelivery",
+			Handler:    _FulfillmentService_CompleteDelivery_Handler,
+		},
+		{
+			MethodName: "GetProofOfDelivery",
+			Handler:    _FulfillmentService_GetProofOfDelivery_Handler,
+		},
+		{
+			MethodName: "GetDeliveryPin",
+			Handler:    _FulfillmentService_GetDeliveryPin_Handler,
+		},
+		{
+			MethodName: "VerifyDeliveryPin",
+			Handler:    _FulfillmentService_VerifyDeliveryPin_Handler,
+		},
+		{
+			MethodName: "OverrideDeliveryPin",
+			Handler:    _FulfillmentService_OverrideDeliveryPin_Handler,
+		},
+		{
+			MethodName: "ReportDeliveryException",
+			Handler:    _FulfillmentService_ReportDeliveryException_Handler,
+		},
+		{
+			MethodName: "CompleteReturn",
+			Handler:    _FulfillmentService_CompleteReturn_Handler,
+		},
+		{
+			MethodName: "SetSlaTarget",
+			Handler:    _FulfillmentService_SetSlaTarget_Handler,
+		},
+		{
+			MethodName: "ListSlaTargets",
+			Handler:    _FulfillmentService_ListSlaTargets_Handler,
+		},
+		{
+			MethodName: "CreateWebhookSubscription",
+			Handler:    _FulfillmentService_CreateWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookSubscriptions",
+			Handler:    _FulfillmentService_ListWebhookSubscriptions_Handler,
+		},
+		{
+			MethodName: "DeleteWebhookSubscription",
+			Handler:    _FulfillmentService_DeleteWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "EnableWebhookSubscription",
+			Handler:    _FulfillmentService_EnableWebhookSubscription_Handler,
+		},
+		{
+			MethodName: "ListWebhookDeliveries",
+			Handler:    _FulfillmentService_ListWebhookDeliveries_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},